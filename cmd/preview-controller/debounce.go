@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"auto-deployer/internal/metrics"
+	"auto-deployer/internal/queue"
+)
+
+// jobCoalescer debounces rapid-fire previewJobs for the same (repo, PR): every
+// opened/reopened/synchronize job is persisted to store and resets a timer;
+// only the newest job for that key is actually handed off once the window
+// elapses, so a PR getting five pushes in ten seconds triggers one build
+// instead of five. The jobs it drops along the way are marked done in store so
+// a worker never picks up a stale one. A "closed" event always supersedes
+// whatever's pending and is handed off immediately. It also tracks the cancel
+// func for whichever build is currently in flight per key, so a newer job that
+// supersedes it can stop the stale build from wasting time.
+type jobCoalescer struct {
+	mu       sync.Mutex
+	window   time.Duration
+	store    *queue.Queue
+	wake     chan<- struct{}
+	timers   map[string]*time.Timer
+	latest   map[string]previewJob
+	queueIDs map[string]uint64
+	cancels  map[string]context.CancelFunc
+}
+
+func newJobCoalescer(window time.Duration, store *queue.Queue, wake chan<- struct{}) *jobCoalescer {
+	return &jobCoalescer{
+		window:   window,
+		store:    store,
+		wake:     wake,
+		timers:   make(map[string]*time.Timer),
+		latest:   make(map[string]previewJob),
+		queueIDs: make(map[string]uint64),
+		cancels:  make(map[string]context.CancelFunc),
+	}
+}
+
+func coalesceKey(repoFullName string, prNumber int) string {
+	return fmt.Sprintf("%s#%d", repoFullName, prNumber)
+}
+
+// enqueue persists job to store and debounces handing it off to a worker. It
+// returns false only when persisting fails; once persisted, the job survives a
+// restart regardless of how long it sits in the debounce window.
+func (c *jobCoalescer) enqueue(logger *slog.Logger, job previewJob) bool {
+	key := coalesceKey(job.previewCfg.RepoFullName, job.previewCfg.PRNumber)
+
+	payload, err := json.Marshal(newQueuedJobPayload(job))
+	if err != nil {
+		logger.Error("encode preview job failed", "error", err)
+		metrics.PreviewQueueDroppedTotal.Inc()
+		return false
+	}
+	queueID, err := c.store.Enqueue(payload)
+	if err != nil {
+		logger.Error("persist preview job failed", "error", err)
+		metrics.PreviewQueueDroppedTotal.Inc()
+		return false
+	}
+	c.refreshQueueDepth(logger)
+
+	c.mu.Lock()
+	if timer, ok := c.timers[key]; ok {
+		timer.Stop()
+		delete(c.timers, key)
+	}
+	previousID, hadPrevious := c.queueIDs[key]
+	delete(c.queueIDs, key)
+	delete(c.latest, key)
+
+	if job.action == "closed" {
+		if cancel, ok := c.cancels[key]; ok {
+			cancel()
+			delete(c.cancels, key)
+		}
+		c.mu.Unlock()
+		if hadPrevious {
+			c.completeSuperseded(logger, previousID)
+		}
+		logger.Info("preview job enqueued", "repo", job.previewCfg.RepoFullName, "pr", job.previewCfg.PRNumber, "action", job.action, "queue_id", queueID)
+		c.signalWake()
+		return true
+	}
+
+	c.latest[key] = job
+	c.queueIDs[key] = queueID
+	c.timers[key] = time.AfterFunc(c.window, func() { c.fire(logger, key) })
+	c.mu.Unlock()
+
+	if hadPrevious {
+		c.completeSuperseded(logger, previousID)
+	}
+	return true
+}
+
+func (c *jobCoalescer) fire(logger *slog.Logger, key string) {
+	c.mu.Lock()
+	job, ok := c.latest[key]
+	queueID := c.queueIDs[key]
+	delete(c.latest, key)
+	delete(c.queueIDs, key)
+	delete(c.timers, key)
+	if cancel, ok := c.cancels[key]; ok {
+		cancel()
+		delete(c.cancels, key)
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	logger.Info("preview job enqueued", "repo", job.previewCfg.RepoFullName, "pr", job.previewCfg.PRNumber, "action", job.action, "queue_id", queueID)
+	c.signalWake()
+}
+
+func (c *jobCoalescer) completeSuperseded(logger *slog.Logger, queueID uint64) {
+	if err := c.store.Complete(queueID); err != nil {
+		logger.Warn("mark superseded preview job done failed", "queue_id", queueID, "error", err)
+	}
+	c.refreshQueueDepth(logger)
+}
+
+// refreshQueueDepth updates the queue-depth gauge from the store's own count,
+// rather than tracking it by hand alongside timers/latest/queueIDs, so it
+// can't drift out of sync with reality.
+func (c *jobCoalescer) refreshQueueDepth(logger *slog.Logger) {
+	stats, err := c.store.Stats()
+	if err != nil {
+		logger.Warn("queue stats failed", "error", err)
+		return
+	}
+	metrics.PreviewQueueDepth.Set(float64(stats.Depth))
+}
+
+func (c *jobCoalescer) signalWake() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// registerBuild records the cancel func for the build currently running for key, so
+// a superseding job can stop it. clearBuild should run (typically via defer) once
+// that build finishes on its own.
+func (c *jobCoalescer) registerBuild(key string, cancel context.CancelFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancels[key] = cancel
+}
+
+func (c *jobCoalescer) clearBuild(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.cancels, key)
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"auto-deployer/internal/github"
+	"auto-deployer/internal/openshift"
+	"auto-deployer/internal/reconcile"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// defaultTailLines is how many lines streamPreviewLogs returns when the
+// caller doesn't ask for a specific tailLines count.
+const defaultTailLines = 200
+
+// newPreviewObservabilityHandler serves
+// GET /previews/{owner}/{repo}/{pr}/logs?container=&follow=1&tailLines=200
+// and GET /previews/{owner}/{repo}/{pr}/events, so a developer can see why a
+// preview is failing without cluster access. Every request is authenticated
+// by calling GET /user/orgs with the caller's own bearer token and checking
+// that the caller belongs to the specific org that owns repoFullName (and
+// that org is itself on allowedOrgs, the same allow-list ParseAllowedOrgs
+// produces for webhook events) -- membership in some other allowed org isn't
+// enough, or a member of org-a could tail logs for org-b's previews.
+func newPreviewObservabilityHandler(logger *slog.Logger, client *openshift.Client, githubAPIBaseURL string, allowedOrgs map[string]struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		repoFullName, prNumber, subresource, err := parsePreviewPath(r.URL.Path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		token := bearerToken(r.Header.Get("Authorization"))
+		if token == "" {
+			http.Error(w, "missing bearer token", http.StatusUnauthorized)
+			return
+		}
+		if len(allowedOrgs) == 0 {
+			http.Error(w, "not a member of an allowed org", http.StatusForbidden)
+			return
+		}
+		allowed, err := github.UserInAllowedOrg(r.Context(), githubAPIBaseURL, token, repoFullName, allowedOrgs)
+		if err != nil {
+			logger.Error("preview observability auth check failed", "error", err)
+			http.Error(w, "authorization check failed", http.StatusBadGateway)
+			return
+		}
+		if !allowed {
+			http.Error(w, "not a member of an allowed org", http.StatusForbidden)
+			return
+		}
+
+		deployment, err := findPreviewDeployment(r.Context(), client, repoFullName, prNumber)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		switch subresource {
+		case "logs":
+			streamPreviewLogs(w, r, logger, client, deployment)
+		case "events":
+			streamPreviewEvents(w, r, client, deployment)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func bearerToken(header string) string {
+	const prefix = "Bearer "
+	if !strings.HasPrefix(header, prefix) {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(header, prefix))
+}
+
+// parsePreviewPath extracts owner/repo, the PR number, and the trailing
+// subresource ("logs" or "events") from a
+// /previews/{owner}/{repo}/{pr}/{subresource} request path.
+func parsePreviewPath(path string) (repoFullName string, prNumber int, subresource string, err error) {
+	parts := strings.Split(strings.TrimPrefix(path, "/previews/"), "/")
+	if len(parts) != 4 || parts[0] == "" || parts[1] == "" || parts[3] == "" {
+		return "", 0, "", fmt.Errorf("expected /previews/{owner}/{repo}/{pr}/{logs,events}")
+	}
+	prNumber, convErr := strconv.Atoi(parts[2])
+	if convErr != nil || prNumber <= 0 {
+		return "", 0, "", fmt.Errorf("invalid pr number: %s", parts[2])
+	}
+	return parts[0] + "/" + parts[1], prNumber, parts[3], nil
+}
+
+// findPreviewDeployment resolves a preview's Deployment by the
+// preview-controller/pr + preview-controller/repo labels reconcile.Labels
+// stamps on it, searching every namespace since the caller doesn't know
+// which namespace mode is configured.
+func findPreviewDeployment(ctx context.Context, client *openshift.Client, repoFullName string, prNumber int) (*appsv1.Deployment, error) {
+	selector := reconcile.PreviewSelector(repoFullName, prNumber)
+	deployments, err := client.Kube.AppsV1().Deployments("").List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("list deployments: %w", err)
+	}
+	if len(deployments.Items) == 0 {
+		return nil, fmt.Errorf("no preview found for %s#%d", repoFullName, prNumber)
+	}
+	return &deployments.Items[0], nil
+}
+
+func streamPreviewLogs(w http.ResponseWriter, r *http.Request, logger *slog.Logger, client *openshift.Client, deployment *appsv1.Deployment) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	pod, err := readyPreviewPod(r.Context(), client, deployment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	tailLines := int64(defaultTailLines)
+	if raw := r.URL.Query().Get("tailLines"); raw != "" {
+		if parsed, convErr := strconv.ParseInt(raw, 10, 64); convErr == nil && parsed > 0 {
+			tailLines = parsed
+		}
+	}
+
+	stream, err := client.Kube.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: r.URL.Query().Get("container"),
+		Follow:    r.URL.Query().Get("follow") == "1",
+		TailLines: &tailLines,
+	}).Stream(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("open log stream: %s", err), http.StatusBadGateway)
+		return
+	}
+	defer stream.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		if _, writeErr := fmt.Fprintf(w, "data: %s\n\n", scanner.Text()); writeErr != nil {
+			return
+		}
+		flusher.Flush()
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Warn("preview log stream ended with error", "pod", pod.Name, "error", err)
+	}
+}
+
+// readyPreviewPod picks a pod to tail logs from: the first Running one it
+// finds, or failing that, whatever pod exists so its Waiting/crash state is
+// still visible.
+func readyPreviewPod(ctx context.Context, client *openshift.Client, deployment *appsv1.Deployment) (*corev1.Pod, error) {
+	pods, err := client.Kube.CoreV1().Pods(deployment.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(deployment.Spec.Selector.MatchLabels).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pods found for preview")
+	}
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == corev1.PodRunning {
+			return &pods.Items[i], nil
+		}
+	}
+	return &pods.Items[0], nil
+}
+
+func streamPreviewEvents(w http.ResponseWriter, r *http.Request, client *openshift.Client, deployment *appsv1.Deployment) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	events, err := previewOwnedEvents(r.Context(), client, deployment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range events {
+		line := fmt.Sprintf("%s %s/%s: %s", event.Reason, event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Message)
+		if _, writeErr := fmt.Fprintf(w, "data: %s\n\n", line); writeErr != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// previewOwnedEvents returns every Event in the preview's namespace whose
+// involved object is the Deployment itself or traces an ownerReference back
+// to it (its ReplicaSets and Pods), oldest first. This is usually more
+// informative than pod logs when a pod never starts.
+func previewOwnedEvents(ctx context.Context, client *openshift.Client, deployment *appsv1.Deployment) ([]corev1.Event, error) {
+	selector := labels.SelectorFromSet(deployment.Spec.Selector.MatchLabels).String()
+	owned := map[string]struct{}{deployment.Name: {}}
+
+	replicaSets, err := client.Kube.AppsV1().ReplicaSets(deployment.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("list replicasets: %w", err)
+	}
+	for _, rs := range replicaSets.Items {
+		if ownedByDeployment(rs.OwnerReferences, deployment.Name) {
+			owned[rs.Name] = struct{}{}
+		}
+	}
+
+	pods, err := client.Kube.CoreV1().Pods(deployment.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		for _, ref := range pod.OwnerReferences {
+			if _, ok := owned[ref.Name]; ok && ref.Kind == "ReplicaSet" {
+				owned[pod.Name] = struct{}{}
+			}
+		}
+	}
+
+	allEvents, err := client.Kube.CoreV1().Events(deployment.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+
+	filtered := make([]corev1.Event, 0, len(allEvents.Items))
+	for _, event := range allEvents.Items {
+		if _, ok := owned[event.InvolvedObject.Name]; ok {
+			filtered = append(filtered, event)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].LastTimestamp.Before(&filtered[j].LastTimestamp)
+	})
+	return filtered, nil
+}
+
+func ownedByDeployment(refs []metav1.OwnerReference, deploymentName string) bool {
+	for _, ref := range refs {
+		if ref.Kind == "Deployment" && ref.Name == deploymentName {
+			return true
+		}
+	}
+	return false
+}
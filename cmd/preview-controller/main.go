@@ -2,9 +2,9 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"log/slog"
 	"net/http"
 	"os"
@@ -18,9 +18,14 @@ import (
 	"auto-deployer/internal/build"
 	"auto-deployer/internal/config"
 	"auto-deployer/internal/github"
+	"auto-deployer/internal/metrics"
 	"auto-deployer/internal/openshift"
+	"auto-deployer/internal/queue"
 	"auto-deployer/internal/reconcile"
 	"auto-deployer/internal/slack"
+	"auto-deployer/internal/webhooks"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const (
@@ -30,6 +35,7 @@ const (
 type previewJob struct {
 	action        string
 	previewCfg    reconcile.PreviewConfig
+	renderer      reconcile.Renderer
 	repoCloneURL  string
 	headSHA       string
 	buildImages   bool
@@ -57,11 +63,18 @@ func main() {
 		logger.Warn("GITHUB_WEBHOOK_SECRET is empty; webhook verification will always fail")
 	}
 
-	githubToken := os.Getenv("GITHUB_TOKEN")
+	gitlabWebhookSecret := os.Getenv("GITLAB_WEBHOOK_SECRET")
+	giteaWebhookSecret := os.Getenv("GITEA_WEBHOOK_SECRET")
+	bitbucketWebhookSecret := os.Getenv("BITBUCKET_WEBHOOK_SECRET")
+
 	githubAPIBaseURL := os.Getenv("GITHUB_API_BASE_URL")
-	githubClient := github.NewClient(githubToken, githubAPIBaseURL)
-	if githubToken == "" {
-		logger.Info("GITHUB_TOKEN is empty; PR comments are disabled")
+	githubClient, err := newGitHubClient(githubAPIBaseURL)
+	if err != nil {
+		logger.Error("failed to configure github client", "error", err)
+		return
+	}
+	if githubClient == nil {
+		logger.Info("GITHUB_TOKEN and GITHUB_APP_ID are both empty; PR comments are disabled")
 	}
 
 	allowedReposRaw := os.Getenv("GITHUB_ALLOWED_REPOS")
@@ -76,6 +89,11 @@ func main() {
 		logger.Warn("GITHUB_REPO_EVENTS_ALLOWED_ORGS is empty; repository events will be rejected")
 	}
 
+	previewLogsAllowedOrgs := github.ParseAllowedOrgs(os.Getenv("GITHUB_PREVIEW_LOGS_ALLOWED_ORGS"))
+	if len(previewLogsAllowedOrgs) == 0 {
+		logger.Warn("GITHUB_PREVIEW_LOGS_ALLOWED_ORGS is empty; the preview logs/events endpoints will reject every request")
+	}
+
 	rejectForks := os.Getenv("GITHUB_REJECT_FORKS") == "true"
 	keepOnMerge := os.Getenv("KEEP_ON_MERGE") == "true"
 	buildImages := os.Getenv("IMAGE_BUILD_ENABLED") == "true"
@@ -91,6 +109,7 @@ func main() {
 	if raw := os.Getenv("IMAGE_BUILD_USE_BUILDX"); raw != "" {
 		useBuildx = raw == "true"
 	}
+	buildSecrets := build.SecretsFromEnv("IMAGE_BUILD_SECRET_")
 
 	mappingPath := os.Getenv("APP_MAPPING_FILE")
 	if mappingPath == "" {
@@ -133,12 +152,6 @@ func main() {
 		}
 	}
 
-	queueSize := 20
-	if raw := os.Getenv("PREVIEW_QUEUE_SIZE"); raw != "" {
-		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
-			queueSize = parsed
-		}
-	}
 	workerCount := 1
 	if raw := os.Getenv("PREVIEW_WORKERS"); raw != "" {
 		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
@@ -146,225 +159,117 @@ func main() {
 		}
 	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-
-	jobCh := make(chan previewJob, queueSize)
-	var workerWG sync.WaitGroup
-	startPreviewWorkers(ctx, &workerWG, logger, client, githubClient, jobCh, workerCount)
-
-	if staleCleanupEnabled {
-		startStaleCleanupLoop(ctx, logger, client, envConfig.NamespaceMode, staleMaxAge, staleCleanupInterval)
+	debounceWindow := 15 * time.Second
+	if raw := os.Getenv("PREVIEW_DEBOUNCE"); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed >= 0 {
+			debounceWindow = parsed
+		}
 	}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/webhook/github", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
+	queueDBPath := os.Getenv("QUEUE_DB_PATH")
+	if queueDBPath == "" {
+		queueDBPath = "data/queue.db"
+	}
+	queueMaxAttempts := 5
+	if raw := os.Getenv("QUEUE_MAX_ATTEMPTS"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			queueMaxAttempts = parsed
 		}
-
-		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
-		if err != nil {
-			logger.Error("webhook body read failed", "error", err)
-			w.WriteHeader(http.StatusBadRequest)
-			return
+	}
+	queueLeaseDuration := 5 * time.Minute
+	if raw := os.Getenv("QUEUE_LEASE_DURATION"); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed > 0 {
+			queueLeaseDuration = parsed
 		}
-
-		signature := r.Header.Get("X-Hub-Signature-256")
-		if !github.VerifySignature(webhookSecret, body, signature) {
-			logger.Warn("invalid webhook signature")
-			w.WriteHeader(http.StatusUnauthorized)
-			return
+	}
+	queueRetention := 24 * time.Hour
+	if raw := os.Getenv("QUEUE_RETENTION"); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed > 0 {
+			queueRetention = parsed
 		}
+	}
+	queuePruneInterval := 15 * time.Minute
+	if raw := os.Getenv("QUEUE_PRUNE_INTERVAL"); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed > 0 {
+			queuePruneInterval = parsed
+		}
+	}
 
-		event := r.Header.Get("X-GitHub-Event")
-		switch event {
-		case github.EventPullRequest:
-			payload, err := github.ParsePullRequestEvent(body)
-			if err != nil {
-				logger.Error("pull_request parse failed", "error", err)
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-
-			if !github.RepoAllowed(allowedRepos, payload.Repository.FullName) {
-				logger.Warn("repo not allowed", "repo", payload.Repository.FullName)
-				w.WriteHeader(http.StatusForbidden)
-				return
-			}
-
-			if rejectForks && payload.PullRequest.Head.Repo.FullName != payload.Repository.FullName {
-				logger.Warn("fork pull request rejected",
-					"repo", payload.Repository.FullName,
-					"head_repo", payload.PullRequest.Head.Repo.FullName,
-					"pr", payload.PullRequest.Number,
-				)
-				w.WriteHeader(http.StatusForbidden)
-				return
-			}
-
-			appConfig, ok := mapping[payload.Repository.FullName]
-			if !ok {
-				logger.Warn("no app mapping found", "repo", payload.Repository.FullName)
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-
-			port := appConfig.ContainerPort
-			if port == 0 {
-				port = envConfig.DefaultPort
-			}
-
-			tag, err := reconcile.ImageTag(envConfig.TagStrategy, payload.PullRequest.Number, payload.PullRequest.Head.SHA)
-			if err != nil {
-				logger.Error("image tag render failed", "error", err)
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-
-			imageRef, err := reconcile.RenderTemplate(envConfig.ImageTemplate, appConfig.AppName, tag, payload.PullRequest.Number)
-			if err != nil {
-				logger.Error("image ref render failed", "error", err)
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-
-			namespace, err := reconcile.NamespaceForMode(envConfig.NamespaceMode, envConfig.BaseNamespace, appConfig.AppName, payload.PullRequest.Number)
-			if err != nil {
-				logger.Error("namespace render failed", "error", err)
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-
-			routeHost, err := reconcile.RenderTemplate(envConfig.RouteTemplate, appConfig.AppName, tag, payload.PullRequest.Number)
-			if err != nil {
-				logger.Error("route host render failed", "error", err)
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-
-			previewCfg := reconcile.PreviewConfig{
-				AppName:       appConfig.AppName,
-				Namespace:     namespace,
-				PRNumber:      payload.PullRequest.Number,
-				RepoFullName:  payload.Repository.FullName,
-				ImageRef:      imageRef,
-				ContainerPort: port,
-				RouteHost:     routeHost,
-				RoutePath:     appConfig.RoutePath,
-				HeadSHA:       payload.PullRequest.Head.SHA,
-				Env:           appConfig.Env,
-			}
-
-			switch payload.Action {
-			case "opened", "reopened", "synchronize":
-				buildCfg := build.Config{
-					Dockerfile: dockerfilePath,
-					Platform:   buildPlatform,
-					UseBuildx:  useBuildx,
-				}
-				job := previewJob{
-					action:        payload.Action,
-					previewCfg:    previewCfg,
-					repoCloneURL:  payload.Repository.CloneURL,
-					headSHA:       payload.PullRequest.Head.SHA,
-					buildImages:   buildImages,
-					buildCfg:      buildCfg,
-					namespaceMode: envConfig.NamespaceMode,
-				}
-				if !enqueueJob(logger, jobCh, job) {
-					w.WriteHeader(http.StatusServiceUnavailable)
-					return
-				}
-			case "closed":
-				if payload.PullRequest.Merged && keepOnMerge {
-					logger.Info("preview kept after merge",
-						"repo", payload.Repository.FullName,
-						"pr", payload.PullRequest.Number,
-					)
-					w.WriteHeader(http.StatusAccepted)
-					return
-				}
-
-				job := previewJob{
-					action:        payload.Action,
-					previewCfg:    previewCfg,
-					headSHA:       payload.PullRequest.Head.SHA,
-					namespaceMode: envConfig.NamespaceMode,
-				}
-				if !enqueueJob(logger, jobCh, job) {
-					w.WriteHeader(http.StatusServiceUnavailable)
-					return
-				}
-			default:
-				logger.Info("pull_request action ignored", "action", payload.Action)
-				w.WriteHeader(http.StatusAccepted)
-				return
-			}
-		case github.EventRepository:
-			if !repoEventsEnabled {
-				logger.Info("repository events disabled")
-				w.WriteHeader(http.StatusAccepted)
-				return
-			}
-
-			payload, err := github.ParseRepositoryEvent(body)
-			if err != nil {
-				logger.Error("repository parse failed", "error", err)
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
+	jobQueue, err := queue.Open(queue.Options{Path: queueDBPath, MaxAttempts: queueMaxAttempts})
+	if err != nil {
+		logger.Error("failed to open job queue", "path", queueDBPath, "error", err)
+		return
+	}
+	defer jobQueue.Close()
 
-			if payload.Action != "created" {
-				logger.Info("repository action ignored", "action", payload.Action)
-				w.WriteHeader(http.StatusAccepted)
-				return
-			}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-			if !github.OrgAllowed(repoEventsAllowedOrgs, payload.Repository.FullName) {
-				logger.Warn("repository org not allowed", "repo", payload.Repository.FullName)
-				w.WriteHeader(http.StatusForbidden)
-				return
-			}
+	wake := make(chan struct{}, 1)
+	coalescer := newJobCoalescer(debounceWindow, jobQueue, wake)
+	var workerWG sync.WaitGroup
+	startPreviewWorkers(ctx, &workerWG, logger, client, githubClient, slackClient, coalescer, jobQueue, mapping, wake, queueLeaseDuration, workerCount)
+	// Kick the workers once at startup so jobs left pending or running (lease
+	// expired) by a previous crash get picked up without waiting for the first
+	// poll tick or the next live webhook.
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
 
-			if slackClient == nil {
-				logger.Warn("slack client not configured; cannot notify", "repo", payload.Repository.FullName)
-				w.WriteHeader(http.StatusAccepted)
-				return
-			}
+	startQueuePruneLoop(ctx, logger, jobQueue, queueRetention, queuePruneInterval)
 
-			desc := payload.Repository.Description
-			if desc == "" {
-				desc = "(no description)"
-			}
+	if staleCleanupEnabled {
+		startStaleCleanupLoop(ctx, logger, client, slackClient, envConfig.NamespaceMode, staleMaxAge, staleCleanupInterval)
+	}
 
-			msg := "New GitHub repo created: `" + payload.Repository.FullName + "`\n" +
-				"URL: " + payload.Repository.HTMLURL + "\n" +
-				"Creator: " + payload.Sender.Login + "\n" +
-				"Description: " + desc + "\n\n" +
-				"Should I add this repo to auto-deployer (`GITHUB_ALLOWED_REPOS` + `config/app-mapping.json`)?"
-
-			notifyCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			if err := slackClient.SendMessage(notifyCtx, msg); err != nil {
-				logger.Error("slack notify failed", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-		default:
-			logger.Info("ignored github event", "event", event)
-		}
+	dispatcher := &previewDispatcher{
+		logger:                logger,
+		mapping:               mapping,
+		envConfig:             envConfig,
+		allowedRepos:          allowedRepos,
+		rejectForks:           rejectForks,
+		keepOnMerge:           keepOnMerge,
+		buildImages:           buildImages,
+		buildCfg:              build.Config{Dockerfile: dockerfilePath, Platform: buildPlatform, UseBuildx: useBuildx, Builder: envConfig.Builder, Secrets: buildSecrets},
+		repoEventsEnabled:     repoEventsEnabled,
+		repoEventsAllowedOrgs: repoEventsAllowedOrgs,
+		slackClient:           slackClient,
+		coalescer:             coalescer,
+	}
 
-		w.WriteHeader(http.StatusAccepted)
-	})
+	mux := http.NewServeMux()
+	mux.Handle("/webhook/github", github.NewWebhookHandler(webhookSecret, dispatcher, 1<<20))
+	mux.Handle("/webhook/gitlab", webhooks.NewWebhookHandler(gitlabWebhookSecret, webhooks.GitLabProvider{}, dispatcher, 1<<20))
+	mux.Handle("/webhook/gitea", webhooks.NewWebhookHandler(giteaWebhookSecret, webhooks.GiteaProvider{}, dispatcher, 1<<20))
+	mux.Handle("/webhook/bitbucket", webhooks.NewWebhookHandler(bitbucketWebhookSecret, webhooks.BitbucketProvider{}, dispatcher, 1<<20))
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
 	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
-		// TODO: wire readiness checks for Kubernetes/OpenShift API.
+		pingCtx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+		if err := client.Ping(pingCtx); err != nil {
+			logger.Error("readiness probe failed", "error", err)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 	})
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/previews/", newPreviewObservabilityHandler(logger, client, githubAPIBaseURL, previewLogsAllowedOrgs))
+	mux.HandleFunc("/queue", func(w http.ResponseWriter, r *http.Request) {
+		stats, err := jobQueue.Stats()
+		if err != nil {
+			logger.Error("queue stats failed", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(stats); err != nil {
+			logger.Error("queue stats encode failed", "error", err)
+		}
+	})
 
 	addr := os.Getenv("LISTEN_ADDR")
 	if addr == "" {
@@ -394,7 +299,6 @@ func main() {
 		return
 	}
 
-	close(jobCh)
 	done := make(chan struct{})
 	go func() {
 		workerWG.Wait()
@@ -409,26 +313,126 @@ func main() {
 	logger.Info("http server stopped")
 }
 
-func startPreviewWorkers(ctx context.Context, wg *sync.WaitGroup, logger *slog.Logger, client *openshift.Client, githubClient *github.Client, jobs <-chan previewJob, count int) {
+// newGitHubClient builds a github.Client from environment configuration. It
+// prefers GitHub App auth (GITHUB_APP_ID + GITHUB_APP_PRIVATE_KEY) over a
+// static GITHUB_TOKEN when both are set, since the App grants each
+// installation its own rate limit instead of sharing one bot account's.
+// Returns a nil Client, nil error when neither is configured.
+func newGitHubClient(apiBaseURL string) (*github.Client, error) {
+	appID := os.Getenv("GITHUB_APP_ID")
+	if appID != "" {
+		var installationID int64
+		if raw := os.Getenv("GITHUB_APP_INSTALLATION_ID"); raw != "" {
+			parsed, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid GITHUB_APP_INSTALLATION_ID: %w", err)
+			}
+			installationID = parsed
+		}
+		return github.NewAppClient(appID, []byte(os.Getenv("GITHUB_APP_PRIVATE_KEY")), apiBaseURL, installationID)
+	}
+
+	return github.NewClient(os.Getenv("GITHUB_TOKEN"), apiBaseURL), nil
+}
+
+// startPreviewWorkers starts count workers that lease jobs from jobQueue and
+// run them. Workers don't read previewJobs off an in-memory channel anymore;
+// wake just tells them "something's claimable" so they don't have to poll
+// constantly, but they fall back to a short poll interval regardless, which is
+// what lets a worker started after a crash reclaim jobs whose lease expired
+// without anyone having to signal it.
+func startPreviewWorkers(ctx context.Context, wg *sync.WaitGroup, logger *slog.Logger, client *openshift.Client, githubClient *github.Client, slackClient *slack.Client, coalescer *jobCoalescer, jobQueue *queue.Queue, mapping reconcile.MappingFile, wake <-chan struct{}, leaseDuration time.Duration, count int) {
 	for i := 0; i < count; i++ {
-		workerID := i + 1
+		workerID := fmt.Sprintf("worker-%d", i+1)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for job := range jobs {
-				jobLogger := logger.With(
-					"worker", workerID,
-					"repo", job.previewCfg.RepoFullName,
-					"pr", job.previewCfg.PRNumber,
-					"sha", job.headSHA,
-				)
-				processPreviewJob(ctx, jobLogger, client, githubClient, job)
-			}
+			runPreviewWorker(ctx, logger, client, githubClient, slackClient, coalescer, jobQueue, mapping, wake, leaseDuration, workerID)
 		}()
 	}
 }
 
-func startStaleCleanupLoop(ctx context.Context, logger *slog.Logger, client *openshift.Client, namespaceMode string, maxAge, interval time.Duration) {
+func runPreviewWorker(ctx context.Context, logger *slog.Logger, client *openshift.Client, githubClient *github.Client, slackClient *slack.Client, coalescer *jobCoalescer, jobQueue *queue.Queue, mapping reconcile.MappingFile, wake <-chan struct{}, leaseDuration time.Duration, workerID string) {
+	pollInterval := 5 * time.Second
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-ticker.C:
+		}
+
+		for {
+			record, err := jobQueue.Lease(workerID, leaseDuration)
+			if err != nil {
+				logger.Error("queue lease failed", "worker", workerID, "error", err)
+				break
+			}
+			if record == nil {
+				break
+			}
+
+			job, ok := rehydratePreviewJob(*record, mapping)
+			if !ok {
+				logger.Error("dropping undecodable queued job", "worker", workerID, "queue_id", record.ID)
+				if err := jobQueue.Fail(record.ID, fmt.Errorf("payload decode failed")); err != nil {
+					logger.Error("mark undecodable job failed failed", "worker", workerID, "queue_id", record.ID, "error", err)
+				}
+				continue
+			}
+
+			jobLogger := logger.With(
+				"worker", workerID,
+				"queue_id", record.ID,
+				"repo", job.previewCfg.RepoFullName,
+				"pr", job.previewCfg.PRNumber,
+				"sha", job.headSHA,
+			)
+			processPreviewJob(ctx, jobLogger, client, githubClient, slackClient, coalescer, jobQueue, record.ID, workerID, leaseDuration, job)
+
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}
+}
+
+// startQueuePruneLoop periodically clears Done/Failed jobs older than
+// retention out of jobQueue, so the store (and every Lease's full bucket
+// scan) stays bounded by actual backlog instead of growing for the life of
+// the process.
+func startQueuePruneLoop(ctx context.Context, logger *slog.Logger, jobQueue *queue.Queue, retention, interval time.Duration) {
+	logger.Info("queue prune enabled",
+		"retention", retention.String(),
+		"interval", interval.String(),
+	)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				removed, err := jobQueue.Prune(retention)
+				if err != nil {
+					logger.Error("queue prune failed", "error", err)
+					continue
+				}
+				if removed > 0 {
+					logger.Info("queue prune finished", "removed", removed)
+				}
+			}
+		}
+	}()
+}
+
+func startStaleCleanupLoop(ctx context.Context, logger *slog.Logger, client *openshift.Client, slackClient *slack.Client, namespaceMode string, maxAge, interval time.Duration) {
 	logger.Info("stale cleanup enabled",
 		"max_age", maxAge.String(),
 		"interval", interval.String(),
@@ -452,6 +456,8 @@ func startStaleCleanupLoop(ctx context.Context, logger *slog.Logger, client *ope
 				"deleted_previews", result.DeletedPreviews,
 				"skipped_deployments", result.SkippedDeployments,
 			)
+			metrics.StaleCleanupDeletedTotal.Add(float64(result.DeletedPreviews))
+			notifyStaleCleanup(logger, slackClient, result)
 		}
 
 		timer := time.NewTimer(30 * time.Second)
@@ -475,85 +481,401 @@ func startStaleCleanupLoop(ctx context.Context, logger *slog.Logger, client *ope
 	}()
 }
 
-func enqueueJob(logger *slog.Logger, jobs chan<- previewJob, job previewJob) bool {
-	select {
-	case jobs <- job:
-		logger.Info("preview job enqueued",
-			"repo", job.previewCfg.RepoFullName,
-			"pr", job.previewCfg.PRNumber,
-			"action", job.action,
+// previewDispatcher implements github.Dispatcher, turning verified webhook events
+// into preview jobs (debounced through coalescer) or, for repository events, a
+// Slack notification.
+type previewDispatcher struct {
+	logger                *slog.Logger
+	mapping               reconcile.MappingFile
+	envConfig             reconcile.EnvConfig
+	allowedRepos          map[string]struct{}
+	rejectForks           bool
+	keepOnMerge           bool
+	buildImages           bool
+	buildCfg              build.Config
+	repoEventsEnabled     bool
+	repoEventsAllowedOrgs map[string]struct{}
+	slackClient           *slack.Client
+	coalescer             *jobCoalescer
+}
+
+// HandlePullRequest satisfies github.Dispatcher; it normalizes the GitHub
+// payload and defers to Handle, which carries the forge-agnostic logic shared
+// with GitLab, Gitea, and Bitbucket.
+func (d *previewDispatcher) HandlePullRequest(ctx context.Context, payload github.PullRequestEvent) error {
+	var installationID int64
+	if payload.Installation != nil {
+		installationID = payload.Installation.ID
+	}
+	return d.Handle(ctx, webhooks.PullRequestEvent{
+		Repo:           payload.Repository.FullName,
+		Number:         payload.PullRequest.Number,
+		HeadSHA:        payload.PullRequest.Head.SHA,
+		CloneURL:       payload.Repository.CloneURL,
+		Action:         payload.Action,
+		Merged:         payload.PullRequest.Merged,
+		IsFork:         payload.PullRequest.Head.Repo.FullName != payload.Repository.FullName,
+		InstallationID: installationID,
+	})
+}
+
+// Handle satisfies webhooks.Handler. It's forge-agnostic: event.Repo is
+// whatever identifier the sending forge uses ("owner/repo" for GitHub and
+// Gitea, "group/project" for GitLab, "workspace/repo" for Bitbucket), keyed
+// straight into MappingFile the same way regardless of where it came from.
+func (d *previewDispatcher) Handle(ctx context.Context, event webhooks.PullRequestEvent) error {
+	if !github.RepoAllowed(d.allowedRepos, event.Repo) {
+		d.logger.Warn("repo not allowed", "repo", event.Repo)
+		return fmt.Errorf("repo not allowed: %s", event.Repo)
+	}
+
+	if d.rejectForks && event.IsFork {
+		d.logger.Warn("fork pull request rejected",
+			"repo", event.Repo,
+			"pr", event.Number,
 		)
-		return true
+		return fmt.Errorf("fork pull requests are rejected")
+	}
+
+	appConfig, ok := d.mapping[event.Repo]
+	if !ok {
+		d.logger.Warn("no app mapping found", "repo", event.Repo)
+		return fmt.Errorf("no app mapping for %s", event.Repo)
+	}
+
+	port := appConfig.ContainerPort
+	if port == 0 {
+		port = d.envConfig.DefaultPort
+	}
+
+	tag, err := reconcile.ImageTag(d.envConfig.TagStrategy, event.Number, event.HeadSHA)
+	if err != nil {
+		return fmt.Errorf("image tag render failed: %w", err)
+	}
+
+	imageRef, err := reconcile.RenderTemplate(d.envConfig.ImageTemplate, appConfig.AppName, tag, event.Number)
+	if err != nil {
+		return fmt.Errorf("image ref render failed: %w", err)
+	}
+
+	namespace, err := reconcile.NamespaceForMode(d.envConfig.NamespaceMode, d.envConfig.BaseNamespace, appConfig.AppName, event.Number)
+	if err != nil {
+		return fmt.Errorf("namespace render failed: %w", err)
+	}
+
+	routeHost, err := reconcile.RenderTemplate(d.envConfig.RouteTemplate, appConfig.AppName, tag, event.Number)
+	if err != nil {
+		return fmt.Errorf("route host render failed: %w", err)
+	}
+
+	previewCfg := reconcile.PreviewConfig{
+		AppName:         appConfig.AppName,
+		Namespace:       namespace,
+		PRNumber:        event.Number,
+		RepoFullName:    event.Repo,
+		ImageRef:        imageRef,
+		ContainerPort:   port,
+		RouteHost:       routeHost,
+		RoutePath:       appConfig.RoutePath,
+		HeadSHA:         event.HeadSHA,
+		Env:             appConfig.Env,
+		NamespacePolicy: d.envConfig.NamespacePolicy,
+		InstallationID:  event.InstallationID,
+	}
+
+	switch event.Action {
+	case "opened", "reopened", "synchronize":
+		buildCfg := d.buildCfg
+		cacheRef, err := reconcile.CacheImageRef(d.envConfig.ImageTemplate, appConfig.AppName, event.Number)
+		if err != nil {
+			return fmt.Errorf("cache ref render failed: %w", err)
+		}
+		buildCfg.CacheRef = cacheRef
+		if len(appConfig.BuildArgs) > 0 {
+			buildCfg.BuildArgs = appConfig.BuildArgs
+		}
+
+		job := previewJob{
+			action:        event.Action,
+			previewCfg:    previewCfg,
+			renderer:      appConfig.Renderer(),
+			repoCloneURL:  event.CloneURL,
+			headSHA:       event.HeadSHA,
+			buildImages:   d.buildImages,
+			buildCfg:      buildCfg,
+			namespaceMode: d.envConfig.NamespaceMode,
+		}
+		if !d.coalescer.enqueue(d.logger, job) {
+			return fmt.Errorf("preview job enqueue failed")
+		}
+	case "closed":
+		if event.Merged && d.keepOnMerge {
+			d.logger.Info("preview kept after merge",
+				"repo", event.Repo,
+				"pr", event.Number,
+			)
+			return nil
+		}
+
+		job := previewJob{
+			action:        event.Action,
+			previewCfg:    previewCfg,
+			headSHA:       event.HeadSHA,
+			namespaceMode: d.envConfig.NamespaceMode,
+		}
+		if !d.coalescer.enqueue(d.logger, job) {
+			return fmt.Errorf("preview job enqueue failed")
+		}
 	default:
-		logger.Error("preview queue full",
-			"repo", job.previewCfg.RepoFullName,
-			"pr", job.previewCfg.PRNumber,
-			"action", job.action,
-		)
-		return false
+		d.logger.Info("pull_request action ignored", "action", event.Action)
+	}
+
+	return nil
+}
+
+func (d *previewDispatcher) HandleRepository(ctx context.Context, payload github.RepositoryEvent) error {
+	if !d.repoEventsEnabled {
+		d.logger.Info("repository events disabled")
+		return nil
+	}
+
+	if payload.Action != "created" {
+		d.logger.Info("repository action ignored", "action", payload.Action)
+		return nil
+	}
+
+	if !github.OrgAllowed(d.repoEventsAllowedOrgs, payload.Repository.FullName) {
+		d.logger.Warn("repository org not allowed", "repo", payload.Repository.FullName)
+		return fmt.Errorf("repository org not allowed: %s", payload.Repository.FullName)
+	}
+
+	if d.slackClient == nil {
+		d.logger.Warn("slack client not configured; cannot notify", "repo", payload.Repository.FullName)
+		return nil
+	}
+
+	desc := payload.Repository.Description
+	if desc == "" {
+		desc = "(no description)"
+	}
+
+	msg := "New GitHub repo created: `" + payload.Repository.FullName + "`\n" +
+		"URL: " + payload.Repository.HTMLURL + "\n" +
+		"Creator: " + payload.Sender.Login + "\n" +
+		"Description: " + desc + "\n\n" +
+		"Should I add this repo to auto-deployer (`GITHUB_ALLOWED_REPOS` + `config/app-mapping.json`)?"
+
+	notifyCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.slackClient.SendMessage(notifyCtx, msg); err != nil {
+		return fmt.Errorf("slack notify failed: %w", err)
+	}
+	return nil
+}
+
+func (d *previewDispatcher) HandlePush(ctx context.Context, payload github.PushEvent) error {
+	d.logger.Info("push event ignored", "repo", payload.Repository.FullName, "ref", payload.Ref)
+	return nil
+}
+
+func (d *previewDispatcher) HandleCheckRun(ctx context.Context, payload github.CheckRunEvent) error {
+	d.logger.Info("check_run event ignored", "repo", payload.Repository.FullName, "name", payload.CheckRun.Name)
+	return nil
+}
+
+func (d *previewDispatcher) HandleInstallation(ctx context.Context, payload github.InstallationEvent) error {
+	d.logger.Info("installation event ignored", "action", payload.Action, "installation_id", payload.Installation.ID)
+	return nil
+}
+
+// processPreviewJob runs job under a renewed queue lease and reports the
+// outcome back to jobQueue: Complete on success, Fail (which schedules a
+// backed-off retry, or gives up past MaxAttempts) otherwise.
+func processPreviewJob(ctx context.Context, logger *slog.Logger, client *openshift.Client, githubClient *github.Client, slackClient *slack.Client, coalescer *jobCoalescer, jobQueue *queue.Queue, queueID uint64, workerID string, leaseDuration time.Duration, job previewJob) {
+	stopRenewal := startLeaseRenewal(ctx, logger, jobQueue, queueID, workerID, leaseDuration)
+	defer stopRenewal()
+
+	if err := runPreviewJob(ctx, logger, client, githubClient, slackClient, coalescer, job); err != nil {
+		if failErr := jobQueue.Fail(queueID, err); failErr != nil {
+			logger.Error("mark preview job failed failed", "error", failErr)
+		}
+		refreshQueueDepthMetric(logger, jobQueue)
+		return
+	}
+	if err := jobQueue.Complete(queueID); err != nil {
+		logger.Error("mark preview job done failed", "error", err)
+	}
+	refreshQueueDepthMetric(logger, jobQueue)
+}
+
+func refreshQueueDepthMetric(logger *slog.Logger, jobQueue *queue.Queue) {
+	stats, err := jobQueue.Stats()
+	if err != nil {
+		logger.Warn("queue stats failed", "error", err)
+		return
+	}
+	metrics.PreviewQueueDepth.Set(float64(stats.Depth))
+}
+
+// startLeaseRenewal renews queueID's lease every minute, mirroring the
+// pipeline-lease keepalive pattern CI runners use to hold a claimed job open
+// across a long-running build. The returned func stops the renewal goroutine
+// and waits for it to exit.
+func startLeaseRenewal(ctx context.Context, logger *slog.Logger, jobQueue *queue.Queue, queueID uint64, workerID string, leaseDuration time.Duration) func() {
+	renewCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-renewCtx.Done():
+				return
+			case <-ticker.C:
+				if err := jobQueue.Renew(queueID, workerID, leaseDuration); err != nil {
+					logger.Warn("queue lease renewal failed", "error", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
 	}
 }
 
-func processPreviewJob(ctx context.Context, logger *slog.Logger, client *openshift.Client, githubClient *github.Client, job previewJob) {
+func runPreviewJob(ctx context.Context, logger *slog.Logger, client *openshift.Client, githubClient *github.Client, slackClient *slack.Client, coalescer *jobCoalescer, job previewJob) error {
 	switch job.action {
 	case "opened", "reopened", "synchronize":
 		if job.buildImages {
 			buildCtx, buildCancel := context.WithTimeout(ctx, 20*time.Minute)
 			defer buildCancel()
 
+			key := coalesceKey(job.previewCfg.RepoFullName, job.previewCfg.PRNumber)
+			coalescer.registerBuild(key, buildCancel)
+			defer coalescer.clearBuild(key)
+
+			cloneURL := job.repoCloneURL
+			if token, tokenErr := githubClient.InstallationToken(buildCtx, job.previewCfg.InstallationID); tokenErr != nil {
+				logger.Warn("github app installation token failed; cloning without credentials", "error", tokenErr)
+			} else if token != "" {
+				cloneURL = github.InjectCloneToken(cloneURL, token)
+			}
+
 			logger.Info("image build starting", "image", job.previewCfg.ImageRef)
-			if err := build.BuildAndPush(buildCtx, job.repoCloneURL, job.headSHA, job.previewCfg.ImageRef, job.buildCfg); err != nil {
+			notifyBuildStarted(ctx, logger, slackClient, job.previewCfg)
+			updatePreviewComment(ctx, logger, githubClient, job.previewCfg, "**Status:** Building image…")
+			buildStarted := time.Now()
+			err := build.BuildAndPush(buildCtx, cloneURL, job.headSHA, job.previewCfg.ImageRef, job.buildCfg)
+			metrics.ObserveBuildDuration(job.previewCfg.RepoFullName, buildStarted, err)
+			if err != nil {
 				logger.Error("image build failed", "error", err)
-				return
+				notifyBuildFailed(ctx, logger, slackClient, job.previewCfg, err)
+				updatePreviewComment(ctx, logger, githubClient, job.previewCfg, previewFailedComment(fmt.Sprintf("image build failed: %s", err)))
+				return fmt.Errorf("image build failed: %w", err)
 			}
 			logger.Info("image build finished", "image", job.previewCfg.ImageRef)
 		}
 
+		updatePreviewComment(ctx, logger, githubClient, job.previewCfg, "**Status:** Deploying…")
+
 		reconcileCtx, reconcileCancel := context.WithTimeout(ctx, 2*time.Minute)
 		defer reconcileCancel()
-		if err := reconcile.UpsertPreview(reconcileCtx, client, job.previewCfg); err != nil {
+		renderer := job.renderer
+		if renderer == nil {
+			renderer = reconcile.BuiltinRenderer{}
+		}
+		reconcileStarted := time.Now()
+		err := reconcile.UpsertPreviewWithRenderer(reconcileCtx, client, job.previewCfg, job.namespaceMode, renderer)
+		metrics.ObserveReconcileDuration("upsert", reconcileStarted, err)
+		if err != nil {
 			logger.Error("preview reconcile failed", "error", err)
-			return
+			updatePreviewComment(ctx, logger, githubClient, job.previewCfg, previewFailedComment(fmt.Sprintf("reconcile failed: %s", err)))
+			return fmt.Errorf("preview reconcile failed: %w", err)
 		}
 		logger.Info("preview reconciled",
 			"namespace", job.previewCfg.Namespace,
 			"route", job.previewCfg.RouteHost,
 		)
-		postPreviewComment(ctx, logger, githubClient, job.previewCfg)
+
+		rolloutCtx, rolloutCancel := context.WithTimeout(ctx, 3*time.Minute)
+		deploymentName := reconcile.ResourcePrefix(job.previewCfg.AppName, job.previewCfg.PRNumber)
+		rollout, err := client.WaitForDeploymentReady(rolloutCtx, job.previewCfg.Namespace, deploymentName, 3*time.Minute)
+		rolloutCancel()
+		if err != nil {
+			logger.Error("rollout wait failed", "error", err)
+			updatePreviewComment(ctx, logger, githubClient, job.previewCfg, previewFailedComment(err.Error()))
+			notifyBuildFailed(ctx, logger, slackClient, job.previewCfg, err)
+			return fmt.Errorf("rollout wait failed: %w", err)
+		}
+		if rollout.Phase != openshift.RolloutReady {
+			reason := rollout.Reason
+			for _, event := range rollout.LastPodEvents {
+				reason += "\n" + event
+			}
+			logger.Error("preview rollout did not become ready", "phase", rollout.Phase, "reason", rollout.Reason)
+			updatePreviewComment(ctx, logger, githubClient, job.previewCfg, previewFailedComment(reason))
+			notifyBuildFailed(ctx, logger, slackClient, job.previewCfg, fmt.Errorf("rollout %s: %s", rollout.Phase, rollout.Reason))
+			return fmt.Errorf("preview rollout %s: %s", rollout.Phase, rollout.Reason)
+		}
+
+		metrics.ActivePreviews.WithLabelValues(job.previewCfg.Namespace).Set(1)
+		if readyComment, err := previewReadyComment(job.previewCfg.RouteHost, job.previewCfg.RoutePath); err != nil {
+			logger.Error("preview URL render failed", "error", err)
+		} else {
+			updatePreviewComment(ctx, logger, githubClient, job.previewCfg, readyComment)
+			logger.Info("github comment posted")
+		}
+		notifyPreviewReady(ctx, logger, slackClient, job.previewCfg)
 	case "closed":
 		deleteCtx, deleteCancel := context.WithTimeout(ctx, 2*time.Minute)
 		defer deleteCancel()
-		if err := reconcile.DeletePreview(deleteCtx, client, job.previewCfg, job.namespaceMode); err != nil {
+		deleteStarted := time.Now()
+		err := reconcile.DeletePreview(deleteCtx, client, job.previewCfg, job.namespaceMode)
+		metrics.ObserveReconcileDuration("delete", deleteStarted, err)
+		if err != nil {
 			logger.Error("preview delete failed", "error", err)
-			return
+			return fmt.Errorf("preview delete failed: %w", err)
 		}
 		logger.Info("preview deleted",
 			"namespace", job.previewCfg.Namespace,
 		)
+		metrics.ActivePreviews.WithLabelValues(job.previewCfg.Namespace).Set(0)
+		notifyPreviewDeleted(ctx, logger, slackClient, job.previewCfg)
 	default:
 		logger.Info("pull_request action ignored", "action", job.action)
 	}
+	return nil
 }
 
-func postPreviewComment(ctx context.Context, logger *slog.Logger, githubClient *github.Client, cfg reconcile.PreviewConfig) {
+// updatePreviewComment writes status as the PR's single tracked preview
+// comment (see github.Client.UpsertPRComment), editing it in place across
+// Building/Deploying/Ready/Failed instead of appending a new comment on
+// every push.
+func updatePreviewComment(ctx context.Context, logger *slog.Logger, githubClient *github.Client, cfg reconcile.PreviewConfig, status string) {
 	if githubClient == nil {
 		return
 	}
-
-	previewURL, err := previewURL(cfg.RouteHost, cfg.RoutePath)
-	if err != nil {
-		logger.Error("preview URL render failed", "error", err)
-		return
-	}
-
-	commentBody := fmt.Sprintf("Preview deployment ready: %s", previewURL)
 	commentCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
-	if err := githubClient.CreatePRComment(commentCtx, cfg.RepoFullName, cfg.PRNumber, commentBody); err != nil {
+	if err := githubClient.UpsertPRComment(commentCtx, cfg.RepoFullName, cfg.PRNumber, status, cfg.InstallationID); err != nil {
 		logger.Error("github comment failed", "error", err)
-		return
 	}
-	logger.Info("github comment posted", "url", previewURL)
+}
+
+func previewReadyComment(routeHost, routePath string) (string, error) {
+	url, err := previewURL(routeHost, routePath)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("**Status:** Ready ✅\n\nPreview: %s", url), nil
+}
+
+func previewFailedComment(reason string) string {
+	return fmt.Sprintf("**Status:** Failed ❌\n\n%s", reason)
 }
 
 func previewURL(routeHost, routePath string) (string, error) {
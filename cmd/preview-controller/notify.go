@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"auto-deployer/internal/reconcile"
+	"auto-deployer/internal/slack"
+)
+
+// notifyBuildStarted, notifyBuildFailed, notifyPreviewReady, notifyPreviewDeleted
+// and notifyStaleCleanup post Block Kit messages for each preview lifecycle
+// transition. They're all best-effort: a Slack failure is logged and
+// swallowed rather than failing the job that triggered it.
+
+func notifyBuildStarted(ctx context.Context, logger *slog.Logger, slackClient *slack.Client, cfg reconcile.PreviewConfig) {
+	if slackClient == nil {
+		return
+	}
+	blocks := []slack.Block{
+		slack.HeaderBlock(fmt.Sprintf("Building preview for PR #%d", cfg.PRNumber)),
+		slack.SectionBlock(
+			fmt.Sprintf("*%s*", cfg.RepoFullName),
+			fmt.Sprintf("*Image:*\n%s", cfg.ImageRef),
+		),
+	}
+	sendNotification(ctx, logger, slackClient, cfg, blocks, fmt.Sprintf("Building preview for %s PR #%d", cfg.RepoFullName, cfg.PRNumber))
+}
+
+func notifyBuildFailed(ctx context.Context, logger *slog.Logger, slackClient *slack.Client, cfg reconcile.PreviewConfig, buildErr error) {
+	if slackClient == nil {
+		return
+	}
+	blocks := []slack.Block{
+		slack.HeaderBlock(fmt.Sprintf("Build failed for PR #%d", cfg.PRNumber)),
+		slack.SectionBlock(fmt.Sprintf("*%s*\n```%s```", cfg.RepoFullName, buildErr.Error())),
+	}
+	sendNotification(ctx, logger, slackClient, cfg, blocks, fmt.Sprintf("Build failed for %s PR #%d", cfg.RepoFullName, cfg.PRNumber))
+}
+
+func notifyPreviewReady(ctx context.Context, logger *slog.Logger, slackClient *slack.Client, cfg reconcile.PreviewConfig) {
+	if slackClient == nil {
+		return
+	}
+	url, err := previewURL(cfg.RouteHost, cfg.RoutePath)
+	if err != nil {
+		logger.Error("preview URL render failed", "error", err)
+		return
+	}
+	blocks := []slack.Block{
+		slack.HeaderBlock(fmt.Sprintf("Preview ready for PR #%d", cfg.PRNumber)),
+		slack.SectionBlock(
+			fmt.Sprintf("*%s*", cfg.RepoFullName),
+			fmt.Sprintf("*Namespace:*\n%s", cfg.Namespace),
+		),
+		slack.ActionsBlock(slack.ButtonElement("Open preview", url)),
+	}
+	sendNotification(ctx, logger, slackClient, cfg, blocks, fmt.Sprintf("Preview ready: %s", url))
+}
+
+func notifyPreviewDeleted(ctx context.Context, logger *slog.Logger, slackClient *slack.Client, cfg reconcile.PreviewConfig) {
+	if slackClient == nil {
+		return
+	}
+	blocks := []slack.Block{
+		slack.HeaderBlock(fmt.Sprintf("Preview deleted for PR #%d", cfg.PRNumber)),
+		slack.SectionBlock(fmt.Sprintf("*%s*\nNamespace `%s` torn down.", cfg.RepoFullName, cfg.Namespace)),
+	}
+	sendNotification(ctx, logger, slackClient, cfg, blocks, fmt.Sprintf("Preview deleted for %s PR #%d", cfg.RepoFullName, cfg.PRNumber))
+}
+
+func notifyStaleCleanup(logger *slog.Logger, slackClient *slack.Client, result reconcile.CleanupResult) {
+	if slackClient == nil || result.DeletedPreviews == 0 {
+		return
+	}
+	blocks := []slack.Block{
+		slack.HeaderBlock("Stale preview cleanup"),
+		slack.SectionBlock(
+			"Scheduled sweep finished.",
+			fmt.Sprintf("*Checked:*\n%d", result.CheckedDeployments),
+			fmt.Sprintf("*Deleted:*\n%d", result.DeletedPreviews),
+			fmt.Sprintf("*Skipped:*\n%d", result.SkippedDeployments),
+		),
+	}
+	notifyCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	fallback := fmt.Sprintf("Stale preview cleanup: deleted %d, checked %d", result.DeletedPreviews, result.CheckedDeployments)
+	if err := slackClient.SendBlocks(notifyCtx, blocks, fallback); err != nil {
+		logger.Error("slack stale cleanup notify failed", "error", err)
+	}
+}
+
+// sendNotification tags ctx with cfg's (repo, PR) so every lifecycle message
+// for this preview threads onto the same Slack message.
+func sendNotification(ctx context.Context, logger *slog.Logger, slackClient *slack.Client, cfg reconcile.PreviewConfig, blocks []slack.Block, fallback string) {
+	notifyCtx, cancel := context.WithTimeout(slack.ContextWithThread(ctx, cfg.RepoFullName, cfg.PRNumber), 5*time.Second)
+	defer cancel()
+	if err := slackClient.SendBlocks(notifyCtx, blocks, fallback); err != nil {
+		logger.Error("slack notify failed", "error", err)
+	}
+}
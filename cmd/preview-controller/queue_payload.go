@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+
+	"auto-deployer/internal/build"
+	"auto-deployer/internal/queue"
+	"auto-deployer/internal/reconcile"
+)
+
+// queuedJobPayload is the JSON-serializable subset of previewJob that gets
+// persisted to the queue store. It omits renderer, which is a reconcile.Renderer
+// interface value and isn't serializable; rehydratePreviewJob looks it back up
+// from the app mapping by repo, exactly as previewDispatcher.Handle does for a
+// live webhook.
+type queuedJobPayload struct {
+	Action        string                  `json:"action"`
+	PreviewCfg    reconcile.PreviewConfig `json:"previewCfg"`
+	RepoCloneURL  string                  `json:"repoCloneURL"`
+	HeadSHA       string                  `json:"headSHA"`
+	BuildImages   bool                    `json:"buildImages"`
+	BuildCfg      build.Config            `json:"buildCfg"`
+	NamespaceMode string                  `json:"namespaceMode"`
+}
+
+func newQueuedJobPayload(job previewJob) queuedJobPayload {
+	return queuedJobPayload{
+		Action:        job.action,
+		PreviewCfg:    job.previewCfg,
+		RepoCloneURL:  job.repoCloneURL,
+		HeadSHA:       job.headSHA,
+		BuildImages:   job.buildImages,
+		BuildCfg:      job.buildCfg,
+		NamespaceMode: job.namespaceMode,
+	}
+}
+
+// rehydratePreviewJob decodes a queue.Job's payload back into a previewJob. It
+// returns false if the payload can't be decoded, which can only happen if an
+// older/newer version of the payload shape got persisted.
+func rehydratePreviewJob(record queue.Job, mapping reconcile.MappingFile) (previewJob, bool) {
+	var payload queuedJobPayload
+	if err := json.Unmarshal(record.Payload, &payload); err != nil {
+		return previewJob{}, false
+	}
+
+	var renderer reconcile.Renderer
+	if appConfig, ok := mapping[payload.PreviewCfg.RepoFullName]; ok {
+		renderer = appConfig.Renderer()
+	}
+
+	return previewJob{
+		action:        payload.Action,
+		previewCfg:    payload.PreviewCfg,
+		renderer:      renderer,
+		repoCloneURL:  payload.RepoCloneURL,
+		headSHA:       payload.HeadSHA,
+		buildImages:   payload.BuildImages,
+		buildCfg:      payload.BuildCfg,
+		namespaceMode: payload.NamespaceMode,
+	}, true
+}
@@ -0,0 +1,232 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Config controls how BuildAndPush builds and pushes a preview image.
+type Config struct {
+	Dockerfile string
+	Platform   string
+	// Builder selects which backend builds the image: "docker" (the
+	// default), "buildx", "buildah", or "kaniko". UseBuildx is a legacy
+	// alias for Builder: "buildx" (set from IMAGE_BUILD_USE_BUILDX), used
+	// only when Builder is empty.
+	Builder   string
+	UseBuildx bool
+	// CacheRef, when set, is where the backend stores and reads a remote
+	// layer cache (buildx's --cache-to/--cache-from, buildah's --cache-to/
+	// --cache-from, or Kaniko's --cache-repo), so repeated pushes for the
+	// same PR reuse unchanged layers instead of rebuilding from scratch.
+	CacheRef string
+	// BuildArgs are passed to every backend as --build-arg. Secrets are
+	// mounted as --secret files by the buildx and buildah backends; the
+	// docker backend does not pass them, and the kaniko backend fails the
+	// build when any are set. BuildArgs
+	// usually comes from the app's mapping entry; Secrets comes from the
+	// controller's own environment, since secret values have no business
+	// living in a mapping file.
+	BuildArgs map[string]string
+	Secrets   map[string]string
+}
+
+// BuildRequest is what a Builder needs to build and push one image. Dir is
+// the repo already cloned to SHA: BuildAndPush does that clone once, up
+// front, so every backend shells out against the same checkout the same way.
+type BuildRequest struct {
+	Dir         string
+	RepoURL     string
+	SHA         string
+	Dockerfile  string
+	Platform    string
+	Destination string
+	BuildArgs   map[string]string
+	Secrets     map[string]string
+	CacheRef    string
+}
+
+// Builder builds and pushes one image for a BuildRequest, returning the
+// image reference that was pushed (normally just req.Destination).
+type Builder interface {
+	Build(ctx context.Context, req BuildRequest) (string, error)
+}
+
+// NewBuilder resolves name (Config.Builder, ultimately the BUILDER env var)
+// to a Builder backend. An empty name keeps the pre-Builder default of
+// plain docker.
+func NewBuilder(name string) (Builder, error) {
+	switch name {
+	case "", "docker":
+		return DockerBuilder{}, nil
+	case "buildx":
+		return BuildxBuilder{}, nil
+	case "buildah":
+		return BuildahBuilder{}, nil
+	case "kaniko":
+		return KanikoBuilder{}, nil
+	default:
+		return nil, fmt.Errorf("unknown BUILDER: %s", name)
+	}
+}
+
+// BuildAndPush clones repoURL at sha into a temp dir and builds+pushes
+// imageRef from it, using the backend cfg.Builder (or the legacy
+// cfg.UseBuildx) selects.
+func BuildAndPush(ctx context.Context, repoURL string, sha string, imageRef string, cfg Config) error {
+	if repoURL == "" {
+		return fmt.Errorf("repo URL is empty")
+	}
+	if sha == "" {
+		return fmt.Errorf("sha is empty")
+	}
+	if imageRef == "" {
+		return fmt.Errorf("image ref is empty")
+	}
+	if cfg.Dockerfile == "" {
+		cfg.Dockerfile = "Dockerfile"
+	}
+	if cfg.Platform == "" {
+		cfg.Platform = "linux/amd64"
+	}
+
+	builderName := cfg.Builder
+	if builderName == "" && cfg.UseBuildx {
+		builderName = "buildx"
+	}
+	builder, err := NewBuilder(builderName)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "preview-build-*")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := cloneAtSHA(ctx, dir, repoURL, sha); err != nil {
+		return err
+	}
+
+	dockerfilePath := filepath.Join(dir, cfg.Dockerfile)
+	if _, err := os.Stat(dockerfilePath); err != nil {
+		return fmt.Errorf("dockerfile not found at %s", dockerfilePath)
+	}
+
+	_, err = builder.Build(ctx, BuildRequest{
+		Dir:         dir,
+		RepoURL:     repoURL,
+		SHA:         sha,
+		Dockerfile:  cfg.Dockerfile,
+		Platform:    cfg.Platform,
+		Destination: imageRef,
+		BuildArgs:   cfg.BuildArgs,
+		Secrets:     cfg.Secrets,
+		CacheRef:    cfg.CacheRef,
+	})
+	return err
+}
+
+func cloneAtSHA(ctx context.Context, dir, repoURL, sha string) error {
+	if err := run(ctx, dir, "git", "init"); err != nil {
+		return err
+	}
+	if err := run(ctx, dir, "git", "remote", "add", "origin", repoURL); err != nil {
+		return err
+	}
+	if err := run(ctx, dir, "git", "fetch", "--depth", "1", "origin", sha); err != nil {
+		return err
+	}
+	return run(ctx, dir, "git", "checkout", "FETCH_HEAD")
+}
+
+// SecretsFromEnv scans the process environment for variables named
+// prefix+NAME and returns them keyed by NAME (lowercased), so an operator can
+// hand a build a secret (e.g. a private registry token a Dockerfile's RUN
+// step needs) without it ever being written to the mapping file.
+func SecretsFromEnv(prefix string) map[string]string {
+	secrets := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		name := strings.ToLower(strings.TrimPrefix(key, prefix))
+		if name == "" {
+			continue
+		}
+		secrets[name] = value
+	}
+	if len(secrets) == 0 {
+		return nil
+	}
+	return secrets
+}
+
+// buildArgFlags renders args as sorted --build-arg key=value pairs, so the
+// command line is deterministic across runs with the same args.
+func buildArgFlags(args map[string]string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	flags := make([]string, 0, len(keys)*2)
+	for _, k := range keys {
+		flags = append(flags, "--build-arg", fmt.Sprintf("%s=%s", k, args[k]))
+	}
+	return flags
+}
+
+// writeSecretFiles materializes secrets as files under dir, one per entry,
+// so a backend that only accepts file-backed build secrets (buildx's and
+// buildah's --secret id=name,src=path) can mount them without the value
+// ever appearing on the command line. It returns the name -> file path
+// mapping that secretFlags takes.
+func writeSecretFiles(dir string, secrets map[string]string) (map[string]string, error) {
+	if len(secrets) == 0 {
+		return nil, nil
+	}
+	secretDir := filepath.Join(dir, ".preview-build-secrets")
+	if err := os.MkdirAll(secretDir, 0o700); err != nil {
+		return nil, fmt.Errorf("create secrets dir: %w", err)
+	}
+
+	paths := make(map[string]string, len(secrets))
+	for name, value := range secrets {
+		path := filepath.Join(secretDir, name)
+		if err := os.WriteFile(path, []byte(value), 0o600); err != nil {
+			return nil, fmt.Errorf("write secret %s: %w", name, err)
+		}
+		paths[name] = path
+	}
+	return paths, nil
+}
+
+// secretFlags renders secretPaths (as produced by writeSecretFiles) as
+// sorted --secret id=name,src=path pairs.
+func secretFlags(secretPaths map[string]string) []string {
+	if len(secretPaths) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(secretPaths))
+	for name := range secretPaths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	flags := make([]string, 0, len(names)*2)
+	for _, name := range names {
+		flags = append(flags, "--secret", fmt.Sprintf("id=%s,src=%s", name, secretPaths[name]))
+	}
+	return flags
+}
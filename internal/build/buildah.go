@@ -0,0 +1,44 @@
+package build
+
+import (
+	"context"
+	"path/filepath"
+)
+
+// BuildahBuilder builds and pushes via `buildah bud`/`buildah push`, run
+// rootless in chroot isolation against vfs storage so it works from inside
+// an unprivileged OpenShift pod, unlike DockerBuilder/BuildxBuilder which
+// need a privileged docker daemon.
+type BuildahBuilder struct{}
+
+func (BuildahBuilder) Build(ctx context.Context, req BuildRequest) (string, error) {
+	dockerfilePath := filepath.Join(req.Dir, req.Dockerfile)
+
+	secretPaths, err := writeSecretFiles(req.Dir, req.Secrets)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{
+		"bud",
+		"--isolation", "chroot",
+		"--storage-driver", "vfs",
+		"--platform", req.Platform,
+		"-f", dockerfilePath,
+		"-t", req.Destination,
+	}
+	args = append(args, buildArgFlags(req.BuildArgs)...)
+	args = append(args, secretFlags(secretPaths)...)
+	if req.CacheRef != "" {
+		args = append(args, "--layers", "--cache-to", req.CacheRef, "--cache-from", req.CacheRef)
+	}
+	args = append(args, req.Dir)
+
+	if err := run(ctx, req.Dir, "buildah", args...); err != nil {
+		return "", err
+	}
+	if err := run(ctx, req.Dir, "buildah", "push", "--storage-driver", "vfs", req.Destination); err != nil {
+		return "", err
+	}
+	return req.Destination, nil
+}
@@ -3,87 +3,97 @@ package build
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 )
 
-type Config struct {
-	Dockerfile string
-	Platform   string
-	UseBuildx  bool
-}
+// logTailLines is how many trailing lines of a failed build command's output
+// get folded into the returned error, so callers (e.g. a Slack notification)
+// can show useful context without the caller needing its own log capture.
+const logTailLines = 20
 
-func BuildAndPush(ctx context.Context, repoURL string, sha string, imageRef string, cfg Config) error {
-	if repoURL == "" {
-		return fmt.Errorf("repo URL is empty")
-	}
-	if sha == "" {
-		return fmt.Errorf("sha is empty")
-	}
-	if imageRef == "" {
-		return fmt.Errorf("image ref is empty")
+// DockerBuilder builds and pushes via the local docker CLI's legacy builder.
+// It needs a privileged docker daemon, unlike BuildahBuilder/KanikoBuilder.
+type DockerBuilder struct{}
+
+func (DockerBuilder) Build(ctx context.Context, req BuildRequest) (string, error) {
+	dockerfilePath := filepath.Join(req.Dir, req.Dockerfile)
+	args := append([]string{"build", "-f", dockerfilePath, "-t", req.Destination}, buildArgFlags(req.BuildArgs)...)
+	args = append(args, ".")
+	if err := run(ctx, req.Dir, "docker", args...); err != nil {
+		return "", err
 	}
-	if cfg.Dockerfile == "" {
-		cfg.Dockerfile = "Dockerfile"
+	if err := run(ctx, req.Dir, "docker", "push", req.Destination); err != nil {
+		return "", err
 	}
-	if cfg.Platform == "" {
-		cfg.Platform = "linux/amd64"
+	if err := run(ctx, req.Dir, "docker", "image", "rm", "-f", req.Destination); err != nil {
+		return "", err
 	}
+	return req.Destination, nil
+}
 
-	dir, err := os.MkdirTemp("", "preview-build-*")
+// BuildxBuilder builds and pushes via `docker buildx build --push`, adding
+// remote layer caching (--cache-to/--cache-from) when req.CacheRef is set so
+// repeated PR pushes reuse unchanged layers instead of rebuilding from
+// scratch.
+type BuildxBuilder struct{}
+
+func (BuildxBuilder) Build(ctx context.Context, req BuildRequest) (string, error) {
+	dockerfilePath := filepath.Join(req.Dir, req.Dockerfile)
+
+	secretPaths, err := writeSecretFiles(req.Dir, req.Secrets)
 	if err != nil {
-		return fmt.Errorf("create temp dir: %w", err)
+		return "", err
 	}
-	defer os.RemoveAll(dir)
 
-	if err := run(ctx, dir, "git", "init"); err != nil {
-		return err
-	}
-	if err := run(ctx, dir, "git", "remote", "add", "origin", repoURL); err != nil {
-		return err
-	}
-	if err := run(ctx, dir, "git", "fetch", "--depth", "1", "origin", sha); err != nil {
-		return err
-	}
-	if err := run(ctx, dir, "git", "checkout", "FETCH_HEAD"); err != nil {
-		return err
+	args := []string{"buildx", "build", "--push", "--tag", req.Destination, "--platform", req.Platform, "-f", dockerfilePath}
+	args = append(args, buildArgFlags(req.BuildArgs)...)
+	args = append(args, secretFlags(secretPaths)...)
+	if req.CacheRef != "" {
+		args = append(args, "--cache-to", "ref="+req.CacheRef+",mode=max", "--cache-from", "ref="+req.CacheRef)
 	}
+	args = append(args, ".")
 
-	dockerfilePath := filepath.Join(dir, cfg.Dockerfile)
-	if _, err := os.Stat(dockerfilePath); err != nil {
-		return fmt.Errorf("dockerfile not found at %s", dockerfilePath)
+	if err := run(ctx, req.Dir, "docker", args...); err != nil {
+		return "", err
 	}
-
-	if cfg.UseBuildx {
-		if err := run(ctx, dir, "docker", "buildx", "build", "--push", "--tag", imageRef, "--platform", cfg.Platform, "-f", dockerfilePath, "."); err != nil {
-			return err
-		}
-		if err := run(ctx, dir, "docker", "image", "rm", "-f", imageRef); err != nil {
-			return err
-		}
-	} else {
-		if err := run(ctx, dir, "docker", "build", "-f", dockerfilePath, "-t", imageRef, "."); err != nil {
-			return err
-		}
-		if err := run(ctx, dir, "docker", "push", imageRef); err != nil {
-			return err
-		}
-		if err := run(ctx, dir, "docker", "image", "rm", "-f", imageRef); err != nil {
-			return err
-		}
+	if err := run(ctx, req.Dir, "docker", "image", "rm", "-f", req.Destination); err != nil {
+		return "", err
 	}
-
-	return nil
+	return req.Destination, nil
 }
 
 func run(ctx context.Context, dir string, name string, args ...string) error {
+	tail := &tailWriter{max: logTailLines}
 	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Dir = dir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = io.MultiWriter(os.Stdout, tail)
+	cmd.Stderr = io.MultiWriter(os.Stderr, tail)
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("%s %v: %w", name, args, err)
+		return fmt.Errorf("%s %v: %w\n--- log tail ---\n%s", name, args, err, tail.String())
 	}
 	return nil
 }
+
+// tailWriter keeps only the last max lines written to it, so a failed
+// command's error can carry a bounded snippet of its output instead of
+// either nothing or an unbounded blob.
+type tailWriter struct {
+	max   int
+	lines []string
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.lines = append(w.lines, strings.Split(string(p), "\n")...)
+	if len(w.lines) > w.max {
+		w.lines = w.lines[len(w.lines)-w.max:]
+	}
+	return len(p), nil
+}
+
+func (w *tailWriter) String() string {
+	return strings.TrimSpace(strings.Join(w.lines, "\n"))
+}
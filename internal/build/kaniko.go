@@ -0,0 +1,42 @@
+package build
+
+import (
+	"context"
+	"fmt"
+)
+
+// KanikoBuilder builds and pushes via Kaniko's executor binary, which (like
+// BuildahBuilder) needs no privileged daemon, so it can run from inside an
+// unprivileged OpenShift pod.
+type KanikoBuilder struct{}
+
+func (KanikoBuilder) Build(ctx context.Context, req BuildRequest) (string, error) {
+	// Kaniko's executor has no equivalent of BuildKit's --secret id=name,src=path
+	// (buildx/buildah's writeSecretFiles/secretFlags): it doesn't implement
+	// `RUN --mount=type=secret`, and its only other route, --build-arg, bakes
+	// the value into the image's history, which isn't a secret at all. Rather
+	// than silently drop req.Secrets or mount them somewhere a Dockerfile
+	// can't portably consume, fail loudly so an operator notices instead of
+	// shipping an image that quietly lacks credentials a build step needed.
+	if len(req.Secrets) > 0 {
+		return "", fmt.Errorf("kaniko builder: secrets are not supported (got %d); switch BUILDER to buildx or buildah to use build secrets", len(req.Secrets))
+	}
+
+	args := []string{
+		fmt.Sprintf("--context=dir://%s", req.Dir),
+		fmt.Sprintf("--dockerfile=%s", req.Dockerfile),
+		fmt.Sprintf("--destination=%s", req.Destination),
+		fmt.Sprintf("--custom-platform=%s", req.Platform),
+	}
+	for k, v := range req.BuildArgs {
+		args = append(args, fmt.Sprintf("--build-arg=%s=%s", k, v))
+	}
+	if req.CacheRef != "" {
+		args = append(args, "--cache=true", fmt.Sprintf("--cache-repo=%s", req.CacheRef))
+	}
+
+	if err := run(ctx, req.Dir, "/kaniko/executor", args...); err != nil {
+		return "", err
+	}
+	return req.Destination, nil
+}
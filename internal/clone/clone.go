@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -34,12 +35,103 @@ func UniquePath(root, repoFullName string, prNumber int, sha string) (string, er
 	return candidate, nil
 }
 
-func WithToken(cloneURL, token string) (string, error) {
+// Auth is a sum type over the ways CloneRepo can authenticate against a remote.
+// NoAuth is for public HTTPS clones, TokenAuth covers GitHub's x-access-token
+// scheme, and SSHAuth covers per-app deploy keys.
+type Auth interface {
+	isAuth()
+}
+
+type NoAuth struct{}
+
+type TokenAuth struct {
+	Token string
+}
+
+type SSHAuth struct {
+	// Key is the PEM-encoded private key contents.
+	Key []byte
+	// KnownHosts, if set, is written alongside Key and passed as
+	// UserKnownHostsFile so StrictHostKeyChecking can stay enabled.
+	KnownHosts []byte
+}
+
+func (NoAuth) isAuth()    {}
+func (TokenAuth) isAuth() {}
+func (SSHAuth) isAuth()   {}
+
+// SubmoduleMode controls whether and how git submodules are fetched.
+type SubmoduleMode string
+
+const (
+	SubmodulesNone      SubmoduleMode = ""
+	SubmodulesShallow   SubmoduleMode = "shallow"
+	SubmodulesRecursive SubmoduleMode = "recursive"
+)
+
+// CloneOptions configures a single CloneRepo call. The zero value is a full clone
+// with no submodules and LFS smudging skipped (GIT_LFS_SKIP_SMUDGE=1).
+type CloneOptions struct {
+	Auth Auth
+
+	// Depth, when > 0, passes --depth to git clone for a shallow history.
+	Depth int
+	// Filter, when set, passes --filter to git clone (e.g. "blob:none" for a
+	// partial clone that defers blob fetches until checkout needs them).
+	Filter string
+	// SingleBranch and Branch restrict the clone to one ref, which combined with
+	// Depth/Filter is the common PR-preview case: only the head ref, shallow,
+	// with blobs fetched on demand.
+	SingleBranch bool
+	Branch       string
+
+	Submodules SubmoduleMode
+	// LFS disables the default GIT_LFS_SKIP_SMUDGE=1 so LFS objects are fetched
+	// normally. Leave false to skip LFS smudging unless the app actually needs it.
+	LFS bool
+}
+
+func (o CloneOptions) cloneArgs(cloneURL, dest string) []string {
+	args := []string{"clone"}
+	if o.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(o.Depth))
+	}
+	if o.Filter != "" {
+		args = append(args, "--filter", o.Filter)
+	}
+	if o.SingleBranch {
+		args = append(args, "--single-branch")
+	}
+	if o.Branch != "" {
+		args = append(args, "--branch", o.Branch)
+	}
+	switch o.Submodules {
+	case SubmodulesShallow:
+		args = append(args, "--recurse-submodules", "--shallow-submodules")
+	case SubmodulesRecursive:
+		args = append(args, "--recurse-submodules")
+	}
+	return append(args, cloneURL, dest)
+}
+
+func (o CloneOptions) lfsEnv() []string {
+	if o.LFS {
+		return nil
+	}
+	return []string{"GIT_LFS_SKIP_SMUDGE=1"}
+}
+
+// WithAuth chooses how to reach cloneURL for auth: TokenAuth rewrites an https://
+// URL to embed the token as the x-access-token user; SSHAuth and NoAuth leave the
+// URL untouched since SSH auth is carried via GIT_SSH_COMMAND instead.
+func WithAuth(cloneURL string, auth Auth) (string, error) {
 	cloneURL = strings.TrimSpace(cloneURL)
 	if cloneURL == "" {
 		return "", fmt.Errorf("clone url is empty")
 	}
-	if token == "" {
+
+	token, ok := auth.(TokenAuth)
+	if !ok || token.Token == "" {
 		return cloneURL, nil
 	}
 
@@ -51,11 +143,11 @@ func WithToken(cloneURL, token string) (string, error) {
 		return cloneURL, nil
 	}
 
-	parsed.User = url.UserPassword("x-access-token", token)
+	parsed.User = url.UserPassword("x-access-token", token.Token)
 	return parsed.String(), nil
 }
 
-func CloneRepo(ctx context.Context, cloneURL, token, dest, sha string) error {
+func CloneRepo(ctx context.Context, cloneURL, dest, sha string, opts CloneOptions) error {
 	if cloneURL == "" {
 		return fmt.Errorf("clone url is empty")
 	}
@@ -66,22 +158,134 @@ func CloneRepo(ctx context.Context, cloneURL, token, dest, sha string) error {
 		return fmt.Errorf("sha is empty")
 	}
 
-	if err := runGit(ctx, token, "clone", cloneURL, dest); err != nil {
+	auth := opts.Auth
+	if auth == nil {
+		auth = NoAuth{}
+	}
+
+	authedURL, err := WithAuth(cloneURL, auth)
+	if err != nil {
+		return err
+	}
+
+	extraEnv, cleanup, err := sshEnv(auth)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	extraEnv = append(extraEnv, opts.lfsEnv()...)
+
+	redact := redactorFor(auth)
+	if err := runGit(ctx, extraEnv, redact, opts.cloneArgs(authedURL, dest)...); err != nil {
 		return fmt.Errorf("git clone failed: %w", err)
 	}
-	if err := runGit(ctx, token, "-C", dest, "checkout", sha); err != nil {
+
+	head, err := revParseHEAD(ctx, extraEnv, redact, dest)
+	if err == nil && head == sha {
+		return nil
+	}
+
+	if err := runGit(ctx, extraEnv, redact, "-C", dest, "fetch", "origin", sha); err != nil {
+		return fmt.Errorf("git fetch failed: %w", err)
+	}
+	if err := runGit(ctx, extraEnv, redact, "-C", dest, "checkout", sha); err != nil {
 		return fmt.Errorf("git checkout failed: %w", err)
 	}
 	return nil
 }
 
-func runGit(ctx context.Context, token string, args ...string) error {
+func revParseHEAD(ctx context.Context, extraEnv []string, redact func(string) string, dest string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", dest, "rev-parse", "HEAD")
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), extraEnv...)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// sshEnv writes the deploy key (and known_hosts, if provided) to a temp file and
+// returns the GIT_SSH_COMMAND env var needed to use it, plus a cleanup func that
+// removes the temp files even if the context is cancelled mid-clone.
+func sshEnv(auth Auth) (env []string, cleanup func(), err error) {
+	ssh, ok := auth.(SSHAuth)
+	if !ok {
+		return nil, func() {}, nil
+	}
+
+	keyFile, err := os.CreateTemp("", "preview-deploy-key-*")
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("create deploy key temp file: %w", err)
+	}
+	keyPath := keyFile.Name()
+	cleanupFiles := []string{keyPath}
+
+	if err := keyFile.Chmod(0o600); err != nil {
+		keyFile.Close()
+		os.Remove(keyPath)
+		return nil, func() {}, fmt.Errorf("chmod deploy key: %w", err)
+	}
+	if _, err := keyFile.Write(ssh.Key); err != nil {
+		keyFile.Close()
+		os.Remove(keyPath)
+		return nil, func() {}, fmt.Errorf("write deploy key: %w", err)
+	}
+	if err := keyFile.Close(); err != nil {
+		os.Remove(keyPath)
+		return nil, func() {}, fmt.Errorf("close deploy key: %w", err)
+	}
+
+	if len(ssh.KnownHosts) == 0 {
+		// Host keys are still checked strictly, against ssh's default
+		// known_hosts files.
+		cleanup = func() { os.Remove(keyPath) }
+		sshCommand := fmt.Sprintf("ssh -i %s -o StrictHostKeyChecking=yes", keyPath)
+		return []string{"GIT_SSH_COMMAND=" + sshCommand}, cleanup, nil
+	}
+
+	knownHostsFile, err := os.CreateTemp("", "preview-known-hosts-*")
+	if err != nil {
+		os.Remove(keyPath)
+		return nil, func() {}, fmt.Errorf("create known_hosts temp file: %w", err)
+	}
+	knownHostsPath := knownHostsFile.Name()
+	cleanupFiles = append(cleanupFiles, knownHostsPath)
+	if _, err := knownHostsFile.Write(ssh.KnownHosts); err != nil {
+		knownHostsFile.Close()
+		os.Remove(keyPath)
+		os.Remove(knownHostsPath)
+		return nil, func() {}, fmt.Errorf("write known_hosts: %w", err)
+	}
+	knownHostsFile.Close()
+
+	cleanup = func() {
+		for _, path := range cleanupFiles {
+			os.Remove(path)
+		}
+	}
+
+	sshCommand := fmt.Sprintf("ssh -i %s -o UserKnownHostsFile=%s -o StrictHostKeyChecking=yes", keyPath, knownHostsPath)
+	return []string{"GIT_SSH_COMMAND=" + sshCommand}, cleanup, nil
+}
+
+func redactorFor(auth Auth) func(string) string {
+	switch a := auth.(type) {
+	case TokenAuth:
+		return func(text string) string { return redactSecret(text, a.Token) }
+	case SSHAuth:
+		return func(text string) string { return redactSecret(text, string(a.Key)) }
+	default:
+		return func(text string) string { return text }
+	}
+}
+
+func runGit(ctx context.Context, extraEnv []string, redact func(string) string, args ...string) error {
 	cmd := exec.CommandContext(ctx, "git", args...)
-	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), extraEnv...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		trimmed := strings.TrimSpace(string(output))
-		trimmed = redactToken(trimmed, token)
+		trimmed = redact(trimmed)
 		if trimmed != "" {
 			return fmt.Errorf("%w: %s", err, trimmed)
 		}
@@ -116,9 +320,9 @@ func validSegment(value string) bool {
 	return true
 }
 
-func redactToken(text, token string) string {
-	if token == "" || text == "" {
+func redactSecret(text, secret string) string {
+	if secret == "" || text == "" {
 		return text
 	}
-	return strings.ReplaceAll(text, token, "REDACTED")
+	return strings.ReplaceAll(text, secret, "REDACTED")
 }
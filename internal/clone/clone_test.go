@@ -0,0 +1,243 @@
+package clone
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCloneOptionsCloneArgs(t *testing.T) {
+	cases := []struct {
+		name string
+		opts CloneOptions
+		want []string
+	}{
+		{
+			name: "full clone",
+			opts: CloneOptions{},
+			want: []string{"clone", "https://example.com/repo.git", "/tmp/dest"},
+		},
+		{
+			name: "pr preview shallow partial clone",
+			opts: CloneOptions{Depth: 1, Filter: "blob:none", SingleBranch: true, Branch: "feature/x"},
+			want: []string{"clone", "--depth", "1", "--filter", "blob:none", "--single-branch", "--branch", "feature/x", "https://example.com/repo.git", "/tmp/dest"},
+		},
+		{
+			name: "recursive submodules",
+			opts: CloneOptions{Submodules: SubmodulesRecursive},
+			want: []string{"clone", "--recurse-submodules", "https://example.com/repo.git", "/tmp/dest"},
+		},
+		{
+			name: "shallow submodules",
+			opts: CloneOptions{Submodules: SubmodulesShallow},
+			want: []string{"clone", "--recurse-submodules", "--shallow-submodules", "https://example.com/repo.git", "/tmp/dest"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := c.opts.cloneArgs("https://example.com/repo.git", "/tmp/dest")
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %v want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestCloneOptionsLFSEnv(t *testing.T) {
+	if got := (CloneOptions{}).lfsEnv(); !reflect.DeepEqual(got, []string{"GIT_LFS_SKIP_SMUDGE=1"}) {
+		t.Fatalf("expected LFS smudge skipped by default, got %v", got)
+	}
+	if got := (CloneOptions{LFS: true}).lfsEnv(); got != nil {
+		t.Fatalf("expected no env override when LFS requested, got %v", got)
+	}
+}
+
+// previewCloneOptions is the shallow/partial/single-branch shape the
+// CloneOptions.SingleBranch comment calls the common PR-preview case: only the
+// head ref, shallow, with blobs deferred.
+func previewCloneOptions(branch string) CloneOptions {
+	return CloneOptions{Depth: 1, Filter: "blob:none", SingleBranch: true, Branch: branch}
+}
+
+// newTestSourceRepo creates a local git repo under t.TempDir() with two
+// commits across a subdirectory, so CloneRepo has something real to clone
+// from without reaching out to a network remote. It returns the repo's
+// file:// URL (required for git to actually honor --depth/--filter against a
+// local path instead of silently ignoring them) and the HEAD SHA.
+func newTestSourceRepo(t *testing.T) (cloneURL, sha string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	run("init", "-q", "--initial-branch=main")
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatalf("write a.txt: %v", err)
+	}
+	run("add", "-A")
+	run("commit", "-q", "-m", "first")
+
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatalf("mkdir sub: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("world\n"), 0o644); err != nil {
+		t.Fatalf("write sub/b.txt: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello\nagain\n"), 0o644); err != nil {
+		t.Fatalf("rewrite a.txt: %v", err)
+	}
+	run("add", "-A")
+	run("commit", "-q", "-m", "second")
+
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("rev-parse HEAD: %v", err)
+	}
+
+	return "file://" + dir, strings.TrimSpace(string(out))
+}
+
+// workingTreeFiles walks dir (skipping .git) and returns a map of relative
+// path -> file contents, for comparing two clones' working trees.
+func workingTreeFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == ".git" || strings.HasPrefix(rel, ".git"+string(filepath.Separator)) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[rel] = string(contents)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk %s: %v", dir, err)
+	}
+	return files
+}
+
+// TestCloneRepoShallowPartialMatchesFullClone is the integration test
+// CloneOptions was added for: a shallow, partial, single-branch clone (the
+// PR-preview case) must check out the exact same working tree at the
+// requested SHA as a plain full clone, even though it fetched far less
+// history and deferred blob content.
+func TestCloneRepoShallowPartialMatchesFullClone(t *testing.T) {
+	cloneURL, sha := newTestSourceRepo(t)
+
+	root := t.TempDir()
+	fullDest := filepath.Join(root, "full")
+	shallowDest := filepath.Join(root, "shallow")
+
+	if err := CloneRepo(context.Background(), cloneURL, fullDest, sha, CloneOptions{}); err != nil {
+		t.Fatalf("full clone: %v", err)
+	}
+	if err := CloneRepo(context.Background(), cloneURL, shallowDest, sha, previewCloneOptions("main")); err != nil {
+		t.Fatalf("shallow partial clone: %v", err)
+	}
+
+	fullHead, err := revParseHEAD(context.Background(), nil, func(s string) string { return s }, fullDest)
+	if err != nil || fullHead != sha {
+		t.Fatalf("full clone HEAD = %q, %v; want %s", fullHead, err, sha)
+	}
+	shallowHead, err := revParseHEAD(context.Background(), nil, func(s string) string { return s }, shallowDest)
+	if err != nil || shallowHead != sha {
+		t.Fatalf("shallow clone HEAD = %q, %v; want %s", shallowHead, err, sha)
+	}
+
+	fullFiles := workingTreeFiles(t, fullDest)
+	shallowFiles := workingTreeFiles(t, shallowDest)
+	if !reflect.DeepEqual(fullFiles, shallowFiles) {
+		t.Fatalf("shallow/partial clone working tree differs from full clone\nfull: %v\nshallow: %v", fullFiles, shallowFiles)
+	}
+}
+
+// BenchmarkCloneRepo compares a full clone against the shallow/partial/
+// single-branch shape CloneOptions was added to support, against the same
+// local repo, so a regression that silently drops a flag from cloneArgs
+// shows up as a timing regression here even before it shows up anywhere else.
+func BenchmarkCloneRepo(b *testing.B) {
+	if _, err := exec.LookPath("git"); err != nil {
+		b.Skip("git not found in PATH")
+	}
+
+	dir := b.TempDir()
+	cloneURL, sha := benchmarkSourceRepo(b, dir)
+
+	b.Run("full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			dest := filepath.Join(b.TempDir(), fmt.Sprintf("dest-%d", i))
+			if err := CloneRepo(context.Background(), cloneURL, dest, sha, CloneOptions{}); err != nil {
+				b.Fatalf("full clone: %v", err)
+			}
+		}
+	})
+
+	b.Run("shallow_partial_single_branch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			dest := filepath.Join(b.TempDir(), fmt.Sprintf("dest-%d", i))
+			if err := CloneRepo(context.Background(), cloneURL, dest, sha, previewCloneOptions("main")); err != nil {
+				b.Fatalf("shallow partial clone: %v", err)
+			}
+		}
+	})
+}
+
+// benchmarkSourceRepo is newTestSourceRepo's *testing.B counterpart.
+func benchmarkSourceRepo(b *testing.B, dir string) (cloneURL, sha string) {
+	b.Helper()
+	run := func(args ...string) {
+		b.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=bench", "GIT_AUTHOR_EMAIL=bench@example.com", "GIT_COMMITTER_NAME=bench", "GIT_COMMITTER_EMAIL=bench@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			b.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	run("init", "-q", "--initial-branch=main")
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello\n"), 0o644); err != nil {
+		b.Fatalf("write a.txt: %v", err)
+	}
+	run("add", "-A")
+	run("commit", "-q", "-m", "first")
+
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		b.Fatalf("rev-parse HEAD: %v", err)
+	}
+	return "file://" + dir, strings.TrimSpace(string(out))
+}
@@ -1,43 +1,350 @@
 package config
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"strings"
 )
 
+// LoadDotenv parses the dotenv file at path and applies each KEY=value pair
+// to the process environment. If override is false, keys that already exist
+// in the environment are left untouched (so real environment variables always
+// win over the file). Double-quoted values are expanded (unquoted and
+// single-quoted values are taken literally), and values are applied one at a time, in file
+// order, so a later value can reference an earlier one via $VAR/${VAR}.
 func LoadDotenv(path string, override bool) error {
-	file, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+	parser := newDotenvParser(path, string(data))
+	for {
+		parser.skipBlankAndComments()
+		if _, ok := parser.peek(); !ok {
+			break
+		}
+
+		entry, err := parser.parseEntry()
+		if err != nil {
+			return err
+		}
+
+		if !override {
+			if _, exists := os.LookupEnv(entry.key); exists {
+				continue
+			}
+		}
+		if err := os.Setenv(entry.key, entry.value); err != nil {
+			return fmt.Errorf("set env %s: %w", entry.key, err)
+		}
+	}
+	return nil
+}
+
+// dotenvEntry is one parsed KEY=value assignment.
+type dotenvEntry struct {
+	key   string
+	value string
+}
+
+// dotenvError reports a parse failure with the line/column it occurred at, so
+// a malformed line in a large .env file (e.g. a GitHub App private key block)
+// is easy to locate.
+type dotenvError struct {
+	path string
+	line int
+	col  int
+	msg  string
+}
+
+func (e *dotenvError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.path, e.line, e.col, e.msg)
+}
+
+// dotenvParser is a small state-machine tokenizer over a dotenv file's raw
+// text. It replaces the previous line-at-a-time, split-on-first-"=" parser so
+// it can handle quoted values that span multiple lines, escape sequences,
+// and variable expansion.
+type dotenvParser struct {
+	path string
+	src  []rune
+	pos  int
+	line int
+	col  int
+}
+
+func newDotenvParser(path, content string) *dotenvParser {
+	return &dotenvParser{path: path, src: []rune(content), line: 1, col: 1}
+}
+
+func (p *dotenvParser) peek() (rune, bool) {
+	if p.pos >= len(p.src) {
+		return 0, false
+	}
+	return p.src[p.pos], true
+}
+
+func (p *dotenvParser) advance() (rune, bool) {
+	r, ok := p.peek()
+	if !ok {
+		return 0, false
+	}
+	p.pos++
+	if r == '\n' {
+		p.line++
+		p.col = 1
+	} else {
+		p.col++
+	}
+	return r, true
+}
+
+func (p *dotenvParser) errorf(format string, args ...interface{}) error {
+	return p.errorfAt(p.line, p.col, format, args...)
+}
+
+func (p *dotenvParser) errorfAt(line, col int, format string, args ...interface{}) error {
+	return &dotenvError{path: p.path, line: line, col: col, msg: fmt.Sprintf(format, args...)}
+}
+
+// skipBlankAndComments advances past blank lines and full-line "#" comments,
+// leaving the parser positioned at the next entry (or EOF).
+func (p *dotenvParser) skipBlankAndComments() {
+	for {
+		p.skipWhile(func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' || r == '\r' })
+		r, ok := p.peek()
+		if !ok || r != '#' {
+			return
+		}
+		p.skipWhile(func(r rune) bool { return r != '\n' })
+	}
+}
+
+// skipInlineSpace advances past spaces/tabs only, stopping at a newline.
+func (p *dotenvParser) skipInlineSpace() {
+	p.skipWhile(func(r rune) bool { return r == ' ' || r == '\t' })
+}
+
+func (p *dotenvParser) skipWhile(match func(rune) bool) {
+	for {
+		r, ok := p.peek()
+		if !ok || !match(r) {
+			return
+		}
+		p.advance()
+	}
+}
+
+// matchKeyword consumes kw if it appears next, followed by inline whitespace
+// (so "export FOO=bar" matches but "exported=bar" doesn't). It leaves the
+// parser untouched if kw isn't present.
+func (p *dotenvParser) matchKeyword(kw string) bool {
+	startPos, startLine, startCol := p.pos, p.line, p.col
+	for _, want := range kw {
+		r, ok := p.advance()
+		if !ok || r != want {
+			p.pos, p.line, p.col = startPos, startLine, startCol
+			return false
+		}
+	}
+	if r, ok := p.peek(); ok && (r == ' ' || r == '\t') {
+		return true
+	}
+	p.pos, p.line, p.col = startPos, startLine, startCol
+	return false
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || (r >= '0' && r <= '9')
+}
+
+// parseEntry parses a single "[export ]KEY=value" line, including any
+// trailing comment; the newline itself is left for the caller.
+func (p *dotenvParser) parseEntry() (dotenvEntry, error) {
+	p.matchKeyword("export")
+	p.skipInlineSpace()
+
+	key, err := p.parseKey()
+	if err != nil {
+		return dotenvEntry{}, err
+	}
+
+	p.skipInlineSpace()
+	if r, ok := p.peek(); !ok || r != '=' {
+		return dotenvEntry{}, p.errorf("expected '=' after variable name %q", key)
+	}
+	p.advance()
+	p.skipInlineSpace()
+
+	var value string
+	switch r, ok := p.peek(); {
+	case ok && r == '\'':
+		value, err = p.parseSingleQuoted()
+	case ok && r == '"':
+		value, err = p.parseDoubleQuoted()
+	default:
+		value = p.parseUnquoted()
+	}
+	if err != nil {
+		return dotenvEntry{}, err
+	}
+
+	if err := p.finishLine(); err != nil {
+		return dotenvEntry{}, err
+	}
+	return dotenvEntry{key: key, value: value}, nil
+}
+
+func (p *dotenvParser) parseKey() (string, error) {
+	r, ok := p.peek()
+	if !ok || !isIdentStart(r) {
+		return "", p.errorf("expected variable name")
+	}
+	var sb strings.Builder
+	for {
+		r, ok := p.peek()
+		if !ok || !isIdentPart(r) {
+			break
 		}
-		key, value, ok := strings.Cut(line, "=")
+		sb.WriteRune(r)
+		p.advance()
+	}
+	return sb.String(), nil
+}
+
+// parseSingleQuoted reads a '...' value literally; nothing inside it is
+// escaped or expanded. It may span multiple lines.
+func (p *dotenvParser) parseSingleQuoted() (string, error) {
+	startLine, startCol := p.line, p.col
+	p.advance() // opening quote
+	var sb strings.Builder
+	for {
+		r, ok := p.advance()
 		if !ok {
-			return fmt.Errorf("invalid dotenv line: %s", line)
+			return "", p.errorfAt(startLine, startCol, "unterminated single-quoted value")
 		}
-		key = strings.TrimSpace(key)
-		value = strings.TrimSpace(value)
-		value = strings.Trim(value, `"'`)
-		if key == "" {
-			return fmt.Errorf("invalid dotenv line: %s", line)
+		if r == '\'' {
+			return sb.String(), nil
 		}
-		if !override {
-			if _, exists := os.LookupEnv(key); exists {
+		sb.WriteRune(r)
+	}
+}
+
+// parseDoubleQuoted reads a "..." value, resolving \n, \t, \\, \" escapes and
+// expanding $VAR / ${VAR} references against the process environment (which
+// reflects every earlier entry in this same file). It may span multiple lines.
+func (p *dotenvParser) parseDoubleQuoted() (string, error) {
+	startLine, startCol := p.line, p.col
+	p.advance() // opening quote
+	var sb strings.Builder
+	for {
+		r, ok := p.advance()
+		if !ok {
+			return "", p.errorfAt(startLine, startCol, "unterminated double-quoted value")
+		}
+		switch r {
+		case '"':
+			return expandVariables(sb.String()), nil
+		case '\\':
+			esc, ok := p.advance()
+			if !ok {
+				return "", p.errorfAt(startLine, startCol, "unterminated double-quoted value")
+			}
+			switch esc {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case '\\':
+				sb.WriteByte('\\')
+			case '"':
+				sb.WriteByte('"')
+			default:
+				sb.WriteRune('\\')
+				sb.WriteRune(esc)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+}
+
+// parseUnquoted reads a bare value, terminated by an unquoted '#' (trailing
+// comment) or newline, with trailing whitespace trimmed.
+func (p *dotenvParser) parseUnquoted() string {
+	var sb strings.Builder
+	p.skipWhile(func(r rune) bool {
+		if r == '\n' || r == '#' {
+			return false
+		}
+		sb.WriteRune(r)
+		return true
+	})
+	return strings.TrimRight(sb.String(), " \t\r")
+}
+
+// finishLine consumes an optional trailing comment and requires the value be
+// followed by a newline or EOF, catching stray trailing characters like
+// KEY="value"oops.
+func (p *dotenvParser) finishLine() error {
+	p.skipInlineSpace()
+	r, ok := p.peek()
+	if !ok || r == '\n' {
+		return nil
+	}
+	if r == '#' {
+		p.skipWhile(func(r rune) bool { return r != '\n' })
+		return nil
+	}
+	return p.errorf("unexpected character %q after value", r)
+}
+
+// expandVariables replaces $VAR and ${VAR} references with their value from
+// the process environment, leaving anything else (including a bare "$" not
+// followed by an identifier or "{") untouched.
+func expandVariables(s string) string {
+	runes := []rune(s)
+	var out strings.Builder
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r != '$' || i+1 >= len(runes) {
+			out.WriteRune(r)
+			continue
+		}
+
+		if runes[i+1] == '{' {
+			end := -1
+			for j := i + 2; j < len(runes); j++ {
+				if runes[j] == '}' {
+					end = j
+					break
+				}
+			}
+			if end == -1 {
+				out.WriteRune(r)
 				continue
 			}
+			out.WriteString(os.Getenv(string(runes[i+2 : end])))
+			i = end
+			continue
 		}
-		if err := os.Setenv(key, value); err != nil {
-			return fmt.Errorf("set env %s: %w", key, err)
+
+		if isIdentStart(runes[i+1]) {
+			j := i + 1
+			for j < len(runes) && isIdentPart(runes[j]) {
+				j++
+			}
+			out.WriteString(os.Getenv(string(runes[i+1 : j])))
+			i = j - 1
+			continue
 		}
+
+		out.WriteRune(r)
 	}
-	return scanner.Err()
+	return out.String()
 }
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDotenv(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write dotenv fixture: %v", err)
+	}
+	return path
+}
+
+func unsetEnv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		os.Unsetenv(key)
+	}
+	t.Cleanup(func() {
+		for _, key := range keys {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestLoadDotenvSyntax(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		keys    []string
+		want    map[string]string
+	}{
+		{
+			name:    "unquoted value with trailing comment",
+			content: "FOO=bar # a comment\n",
+			keys:    []string{"FOO"},
+			want:    map[string]string{"FOO": "bar"},
+		},
+		{
+			name:    "single quoted value is literal",
+			content: `FOO='bar $BAZ \n'` + "\n",
+			keys:    []string{"FOO"},
+			want:    map[string]string{"FOO": `bar $BAZ \n`},
+		},
+		{
+			name:    "double quoted value with escapes",
+			content: `FOO="line one\nline two\ttabbed \"quoted\""` + "\n",
+			keys:    []string{"FOO"},
+			want:    map[string]string{"FOO": "line one\nline two\ttabbed \"quoted\""},
+		},
+		{
+			name:    "double quoted value spans multiple lines",
+			content: "FOO=\"line one\nline two\"\n",
+			keys:    []string{"FOO"},
+			want:    map[string]string{"FOO": "line one\nline two"},
+		},
+		{
+			name:    "export prefix",
+			content: "export FOO=bar\n",
+			keys:    []string{"FOO"},
+			want:    map[string]string{"FOO": "bar"},
+		},
+		{
+			name:    "identifier starting with export is not treated as keyword",
+			content: "exported=bar\n",
+			keys:    []string{"exported"},
+			want:    map[string]string{"exported": "bar"},
+		},
+		{
+			name:    "variable expansion against earlier entries",
+			content: "FOO=bar\nBAZ=\"${FOO}-${FOO}\"\nQUX=\"$FOO!\"\n",
+			keys:    []string{"FOO", "BAZ", "QUX"},
+			want:    map[string]string{"FOO": "bar", "BAZ": "bar-bar", "QUX": "bar!"},
+		},
+		{
+			name:    "blank lines and full line comments are ignored",
+			content: "# leading comment\n\nFOO=bar\n",
+			keys:    []string{"FOO"},
+			want:    map[string]string{"FOO": "bar"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			unsetEnv(t, c.keys...)
+			path := writeDotenv(t, c.content)
+			if err := LoadDotenv(path, true); err != nil {
+				t.Fatalf("LoadDotenv: %v", err)
+			}
+			for key, want := range c.want {
+				if got := os.Getenv(key); got != want {
+					t.Fatalf("%s: got %q want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadDotenvOverride(t *testing.T) {
+	unsetEnv(t, "FOO")
+	os.Setenv("FOO", "preexisting")
+	path := writeDotenv(t, "FOO=fromfile\n")
+
+	if err := LoadDotenv(path, false); err != nil {
+		t.Fatalf("LoadDotenv: %v", err)
+	}
+	if got := os.Getenv("FOO"); got != "preexisting" {
+		t.Fatalf("expected override=false to leave existing value, got %q", got)
+	}
+
+	if err := LoadDotenv(path, true); err != nil {
+		t.Fatalf("LoadDotenv: %v", err)
+	}
+	if got := os.Getenv("FOO"); got != "fromfile" {
+		t.Fatalf("expected override=true to replace existing value, got %q", got)
+	}
+}
+
+func TestLoadDotenvSyntaxErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "missing equals",
+			content: "FOO bar\n",
+			wantErr: "1:5",
+		},
+		{
+			name:    "unterminated double quote",
+			content: "FOO=\"bar\n",
+			wantErr: "1:5",
+		},
+		{
+			name:    "unterminated single quote",
+			content: "FOO='bar\n",
+			wantErr: "1:5",
+		},
+		{
+			name:    "trailing garbage after quoted value",
+			content: "FOO=\"bar\"baz\n",
+			wantErr: "1:10",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := writeDotenv(t, c.content)
+			err := LoadDotenv(path, true)
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), c.wantErr) {
+				t.Fatalf("expected error to contain %q, got %q", c.wantErr, err.Error())
+			}
+		})
+	}
+}
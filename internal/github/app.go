@@ -0,0 +1,183 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// appJWTTTL is how long a generated App JWT is valid for. GitHub rejects
+// anything over 10 minutes; staying well under that also bounds how stale a
+// clock-skewed iat can get away with being.
+const appJWTTTL = 9 * time.Minute
+
+// installationTokenRefreshSkew is how long before expiry a cached installation
+// token is treated as expired, so a request started just before the real
+// expiry doesn't get a token that dies mid-flight.
+const installationTokenRefreshSkew = 5 * time.Minute
+
+// AppAuth mints short-lived GitHub App installation tokens: it signs an RS256
+// JWT asserting the App's identity, then exchanges it for a per-installation
+// token via the installations access_tokens endpoint. Tokens are cached per
+// installation until they're close to expiring, so using the App across many
+// webhook deliveries for the same installation doesn't mint a fresh token
+// every time.
+type AppAuth struct {
+	appID      string
+	privateKey *rsa.PrivateKey
+	baseURL    string
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	tokens map[int64]cachedInstallationToken
+}
+
+type cachedInstallationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// NewAppAuth parses privateKeyPEM (a GitHub App's RSA private key, PKCS#1 or
+// PKCS#8, PEM-encoded) and returns an AppAuth that signs as appID.
+func NewAppAuth(appID string, privateKeyPEM []byte, baseURL string) (*AppAuth, error) {
+	if appID == "" {
+		return nil, fmt.Errorf("app id is empty")
+	}
+	key, err := parseRSAPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("parse app private key: %w", err)
+	}
+	if baseURL == "" {
+		baseURL = defaultAPIBaseURL
+	}
+	return &AppAuth{
+		appID:      appID,
+		privateKey: key,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		tokens:     make(map[int64]cachedInstallationToken),
+	}, nil
+}
+
+func parseRSAPrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported private key encoding: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not RSA")
+	}
+	return key, nil
+}
+
+// jwt returns a freshly signed RS256 JWT asserting this App's identity
+// (iss=appID), valid from 30 seconds ago (clock-skew allowance) for appJWTTTL.
+func (a *AppAuth) jwt() (string, error) {
+	now := time.Now().Add(-30 * time.Second)
+	headerJSON, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(struct {
+		IssuedAt int64  `json:"iat"`
+		Expiry   int64  `json:"exp"`
+		Issuer   string `json:"iss"`
+	}{
+		IssuedAt: now.Unix(),
+		Expiry:   now.Add(appJWTTTL).Unix(),
+		Issuer:   a.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	hashed := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", fmt.Errorf("sign app jwt: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// installationToken returns a token scoped to installationID, minting a new
+// one if nothing cached is fresh enough.
+func (a *AppAuth) installationToken(ctx context.Context, installationID int64) (string, error) {
+	a.mu.Lock()
+	cached, ok := a.tokens[installationID]
+	a.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt.Add(-installationTokenRefreshSkew)) {
+		return cached.token, nil
+	}
+
+	jwt, err := a.jwt()
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", a.baseURL, installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("create installation token request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("User-Agent", "auto-deployer")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("send installation token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 16<<10))
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("installation token request failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+
+	var parsed struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(bodyBytes, &parsed); err != nil {
+		return "", fmt.Errorf("decode installation token response: %w", err)
+	}
+
+	a.mu.Lock()
+	a.tokens[installationID] = cachedInstallationToken{token: parsed.Token, expiresAt: parsed.ExpiresAt}
+	a.mu.Unlock()
+
+	return parsed.Token, nil
+}
+
+// InjectCloneToken returns cloneURL with token embedded as the password (user
+// x-access-token) in an HTTP Basic auth userinfo component
+// (x-access-token:<token>@host/...), the
+// scheme GitHub App installation tokens use for git over HTTPS. cloneURL is
+// returned unchanged if token is empty or cloneURL isn't https.
+func InjectCloneToken(cloneURL, token string) string {
+	if token == "" || !strings.HasPrefix(cloneURL, "https://") {
+		return cloneURL
+	}
+	return "https://x-access-token:" + token + "@" + strings.TrimPrefix(cloneURL, "https://")
+}
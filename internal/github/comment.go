@@ -13,10 +13,20 @@ import (
 
 const defaultAPIBaseURL = "https://api.github.com"
 
+// previewCommentMarker is prefixed onto every comment UpsertPRComment writes,
+// so later calls can find and edit it instead of appending a new comment on
+// every push.
+const previewCommentMarker = "<!-- preview-controller -->"
+
+// Client talks to the GitHub REST API, authenticating either with a single
+// static token (NewClient) or, for higher rate limits and multi-org support,
+// as a GitHub App minting per-installation tokens (NewAppClient).
 type Client struct {
-	baseURL    string
-	token      string
-	httpClient *http.Client
+	baseURL               string
+	token                 string
+	appAuth               *AppAuth
+	defaultInstallationID int64
+	httpClient            *http.Client
 }
 
 func NewClient(token, baseURL string) *Client {
@@ -33,7 +43,89 @@ func NewClient(token, baseURL string) *Client {
 	}
 }
 
-func (c *Client) CreatePRComment(ctx context.Context, repoFullName string, prNumber int, body string) error {
+// NewAppClient configures a Client that authenticates as a GitHub App,
+// minting short-lived installation tokens instead of using one shared PAT.
+// defaultInstallationID is used when a caller doesn't specify one (e.g. an API
+// call not triggered by a webhook carrying its own installation id); it may
+// be zero if every call always supplies one.
+func NewAppClient(appID string, privateKeyPEM []byte, baseURL string, defaultInstallationID int64) (*Client, error) {
+	appAuth, err := NewAppAuth(appID, privateKeyPEM, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if baseURL == "" {
+		baseURL = defaultAPIBaseURL
+	}
+	return &Client{
+		baseURL:               strings.TrimRight(baseURL, "/"),
+		appAuth:               appAuth,
+		defaultInstallationID: defaultInstallationID,
+		httpClient:            &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// tokenFor resolves the Authorization token to use for a request: the static
+// PAT if this Client isn't App-authenticated, otherwise a cached or freshly
+// minted token for installationID (falling back to defaultInstallationID when
+// installationID is zero).
+func (c *Client) tokenFor(ctx context.Context, installationID int64) (string, error) {
+	if c.appAuth == nil {
+		return c.token, nil
+	}
+	if installationID == 0 {
+		installationID = c.defaultInstallationID
+	}
+	if installationID == 0 {
+		return "", fmt.Errorf("github app auth requires an installation id")
+	}
+	return c.appAuth.installationToken(ctx, installationID)
+}
+
+// InstallationToken returns a short-lived token scoped to installationID
+// (falling back to the configured default when zero), suitable for embedding
+// in a git clone URL via InjectCloneToken. It returns an empty token, with no
+// error, when this Client isn't App-authenticated: there's nothing to inject,
+// and callers should fall back to cloning unauthenticated.
+func (c *Client) InstallationToken(ctx context.Context, installationID int64) (string, error) {
+	if c == nil || c.appAuth == nil {
+		return "", nil
+	}
+	return c.tokenFor(ctx, installationID)
+}
+
+// CreatePRComment posts body as a new comment on the given PR. installationID
+// selects which GitHub App installation to authenticate as when this Client
+// is App-authenticated; it's ignored for a static-token Client.
+func (c *Client) CreatePRComment(ctx context.Context, repoFullName string, prNumber int, body string, installationID int64) error {
+	if c == nil {
+		return fmt.Errorf("github client is nil")
+	}
+	owner, repo, err := splitRepoFullName(repoFullName)
+	if err != nil {
+		return err
+	}
+	if prNumber <= 0 {
+		return fmt.Errorf("invalid pr number: %d", prNumber)
+	}
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("comment body is empty")
+	}
+
+	token, err := c.tokenFor(ctx, installationID)
+	if err != nil {
+		return fmt.Errorf("resolve github token: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", c.baseURL, owner, repo, prNumber)
+	return c.sendCommentRequest(ctx, http.MethodPost, url, body, token, http.StatusCreated)
+}
+
+// UpsertPRComment posts body as a single comment that tracks a preview's
+// lifecycle (Building → Deploying → Ready/Failed) across every push to the
+// PR: it finds the previous call's comment via previewCommentMarker and
+// edits it in place instead of appending a new one. The marker is added
+// automatically; body should just be the current status.
+func (c *Client) UpsertPRComment(ctx context.Context, repoFullName string, prNumber int, body string, installationID int64) error {
 	if c == nil {
 		return fmt.Errorf("github client is nil")
 	}
@@ -44,6 +136,91 @@ func (c *Client) CreatePRComment(ctx context.Context, repoFullName string, prNum
 	if prNumber <= 0 {
 		return fmt.Errorf("invalid pr number: %d", prNumber)
 	}
+
+	token, err := c.tokenFor(ctx, installationID)
+	if err != nil {
+		return fmt.Errorf("resolve github token: %w", err)
+	}
+
+	markedBody := previewCommentMarker + "\n" + strings.TrimSpace(body)
+
+	existingID, err := c.findMarkerComment(ctx, owner, repo, prNumber, token)
+	if err != nil {
+		return fmt.Errorf("find existing preview comment: %w", err)
+	}
+	if existingID == 0 {
+		url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", c.baseURL, owner, repo, prNumber)
+		return c.sendCommentRequest(ctx, http.MethodPost, url, markedBody, token, http.StatusCreated)
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/comments/%d", c.baseURL, owner, repo, existingID)
+	return c.sendCommentRequest(ctx, http.MethodPatch, url, markedBody, token, http.StatusOK)
+}
+
+// maxCommentPages bounds how many pages of comments findMarkerComment will
+// walk before giving up, so a PR that somehow has an unbounded comment
+// history can't make it loop forever.
+const maxCommentPages = 100
+
+type prComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// findMarkerComment returns the id of the first comment on the PR carrying
+// previewCommentMarker, or 0 if there isn't one yet. It walks every page of
+// comments (GitHub returns at most 100 per page) since the marker comment is
+// usually one of the oldest on the PR and a PR can easily outgrow one page.
+func (c *Client) findMarkerComment(ctx context.Context, owner, repo string, prNumber int, token string) (int64, error) {
+	for page := 1; page <= maxCommentPages; page++ {
+		comments, err := c.listPRComments(ctx, owner, repo, prNumber, page, token)
+		if err != nil {
+			return 0, err
+		}
+		for _, comment := range comments {
+			if strings.Contains(comment.Body, previewCommentMarker) {
+				return comment.ID, nil
+			}
+		}
+		if len(comments) < 100 {
+			return 0, nil
+		}
+	}
+	return 0, nil
+}
+
+func (c *Client) listPRComments(ctx context.Context, owner, repo string, prNumber, page int, token string) ([]prComment, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments?per_page=100&page=%d", c.baseURL, owner, repo, prNumber, page)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("list comments request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("User-Agent", "auto-deployer")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send list comments request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, 512<<10))
+	if err != nil {
+		return nil, fmt.Errorf("read list comments response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list comments request failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+
+	var comments []prComment
+	if err := json.Unmarshal(bodyBytes, &comments); err != nil {
+		return nil, fmt.Errorf("decode list comments response: %w", err)
+	}
+	return comments, nil
+}
+
+func (c *Client) sendCommentRequest(ctx context.Context, method, url, body, token string, wantStatus int) error {
 	if strings.TrimSpace(body) == "" {
 		return fmt.Errorf("comment body is empty")
 	}
@@ -54,13 +231,12 @@ func (c *Client) CreatePRComment(ctx context.Context, repoFullName string, prNum
 		return fmt.Errorf("marshal comment payload: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", c.baseURL, owner, repo, prNumber)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
 	if err != nil {
 		return fmt.Errorf("create comment request: %w", err)
 	}
 	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "auto-deployer")
 
@@ -70,7 +246,7 @@ func (c *Client) CreatePRComment(ctx context.Context, repoFullName string, prNum
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
+	if resp.StatusCode != wantStatus {
 		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<10))
 		return fmt.Errorf("comment request failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
 	}
@@ -1,6 +1,14 @@
 package github
 
-import "strings"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
 
 func ParseAllowedOrgs(raw string) map[string]struct{} {
 	orgs := make(map[string]struct{})
@@ -26,3 +34,82 @@ func OrgAllowed(allowed map[string]struct{}, repoFullName string) bool {
 	_, ok := allowed[org]
 	return ok
 }
+
+// UserOrgs returns the logins of the organizations the GitHub user identified
+// by token belongs to, via GET /user/orgs. Only the first page of that response
+// is read; further pages are not fetched. token is the caller's own GitHub
+// token (e.g. a developer's PAT or OAuth token) rather than this controller's
+// App or PAT credentials, so this is a free function rather than a Client
+// method.
+func UserOrgs(ctx context.Context, baseURL, token string) ([]string, error) {
+	if baseURL == "" {
+		baseURL = defaultAPIBaseURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/user/orgs", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create user orgs request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("User-Agent", "auto-deployer")
+
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send user orgs request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, 512<<10))
+	if err != nil {
+		return nil, fmt.Errorf("read user orgs response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user orgs request failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+
+	var orgs []struct {
+		Login string `json:"login"`
+	}
+	if err := json.Unmarshal(bodyBytes, &orgs); err != nil {
+		return nil, fmt.Errorf("decode user orgs response: %w", err)
+	}
+
+	logins := make([]string, 0, len(orgs))
+	for _, org := range orgs {
+		logins = append(logins, org.Login)
+	}
+	return logins, nil
+}
+
+// UserInAllowedOrg reports whether repoFullName's owning org is itself
+// allowed (as produced by ParseAllowedOrgs), and token's GitHub user is
+// actually a member of that same org -- not just a member of some other org
+// that happens to be on the allow-list. An empty allow-list permits
+// everyone, matching OrgAllowed's behavior.
+func UserInAllowedOrg(ctx context.Context, baseURL, token, repoFullName string, allowed map[string]struct{}) (bool, error) {
+	if !OrgAllowed(allowed, repoFullName) {
+		return false, nil
+	}
+	if len(allowed) == 0 {
+		return true, nil
+	}
+
+	parts := strings.SplitN(repoFullName, "/", 2)
+	if len(parts) != 2 {
+		return false, fmt.Errorf("invalid repo full name: %s", repoFullName)
+	}
+	repoOrg := strings.ToLower(parts[0])
+
+	orgs, err := UserOrgs(ctx, baseURL, token)
+	if err != nil {
+		return false, err
+	}
+	for _, org := range orgs {
+		if strings.ToLower(org) == repoOrg {
+			return true, nil
+		}
+	}
+	return false, nil
+}
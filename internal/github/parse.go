@@ -5,8 +5,11 @@ import (
 )
 
 const (
-	EventPullRequest = "pull_request"
-	EventRepository  = "repository"
+	EventPullRequest  = "pull_request"
+	EventRepository   = "repository"
+	EventPush         = "push"
+	EventCheckRun     = "check_run"
+	EventInstallation = "installation"
 )
 
 func ParsePullRequestEvent(body []byte) (PullRequestEvent, error) {
@@ -24,3 +27,27 @@ func ParseRepositoryEvent(body []byte) (RepositoryEvent, error) {
 	}
 	return event, nil
 }
+
+func ParsePushEvent(body []byte) (PushEvent, error) {
+	var event PushEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return PushEvent{}, err
+	}
+	return event, nil
+}
+
+func ParseCheckRunEvent(body []byte) (CheckRunEvent, error) {
+	var event CheckRunEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return CheckRunEvent{}, err
+	}
+	return event, nil
+}
+
+func ParseInstallationEvent(body []byte) (InstallationEvent, error) {
+	var event InstallationEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return InstallationEvent{}, err
+	}
+	return event, nil
+}
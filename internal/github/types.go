@@ -1,15 +1,57 @@
 package github
 
 type PullRequestEvent struct {
-	Action      string `json:"action"`
-	Repository  Repo   `json:"repository"`
-	PullRequest PR     `json:"pull_request"`
+	Action       string           `json:"action"`
+	Repository   Repo             `json:"repository"`
+	PullRequest  PR               `json:"pull_request"`
+	Installation *InstallationRef `json:"installation,omitempty"`
+}
+
+// InstallationRef identifies the GitHub App installation a webhook delivery
+// came through. It's present on events delivered to an App's webhook
+// endpoint, even for event types (like pull_request) that don't otherwise
+// mention installations.
+type InstallationRef struct {
+	ID int64 `json:"id"`
 }
 
 type RepositoryEvent struct {
 	Action     string `json:"action"`
-	Repository Repo    `json:"repository"`
-	Sender     User    `json:"sender"`
+	Repository Repo   `json:"repository"`
+	Sender     User   `json:"sender"`
+}
+
+type PushEvent struct {
+	Ref        string `json:"ref"`
+	Before     string `json:"before"`
+	After      string `json:"after"`
+	Repository Repo   `json:"repository"`
+	Sender     User   `json:"sender"`
+}
+
+type CheckRunEvent struct {
+	Action     string   `json:"action"`
+	CheckRun   CheckRun `json:"check_run"`
+	Repository Repo     `json:"repository"`
+}
+
+type CheckRun struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HeadSHA    string `json:"head_sha"`
+}
+
+type InstallationEvent struct {
+	Action       string       `json:"action"`
+	Installation Installation `json:"installation"`
+	Sender       User         `json:"sender"`
+}
+
+type Installation struct {
+	ID      int64 `json:"id"`
+	Account User  `json:"account"`
 }
 
 type Repo struct {
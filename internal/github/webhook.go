@@ -0,0 +1,115 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"auto-deployer/internal/metrics"
+)
+
+// deliveryIDKey is the context key NewWebhookHandler stamps X-GitHub-Delivery under
+// so downstream logging can correlate a dispatch back to the original webhook call.
+type deliveryIDKey struct{}
+
+// DeliveryID returns the X-GitHub-Delivery header value carried on ctx, if any.
+func DeliveryID(ctx context.Context) string {
+	id, _ := ctx.Value(deliveryIDKey{}).(string)
+	return id
+}
+
+// Dispatcher handles parsed, signature-verified webhook events. Handlers return an
+// error to signal the event couldn't be processed; NewWebhookHandler responds 400
+// in that case but does not retry.
+type Dispatcher interface {
+	HandlePullRequest(ctx context.Context, event PullRequestEvent) error
+	HandleRepository(ctx context.Context, event RepositoryEvent) error
+	HandlePush(ctx context.Context, event PushEvent) error
+	HandleCheckRun(ctx context.Context, event CheckRunEvent) error
+	HandleInstallation(ctx context.Context, event InstallationEvent) error
+}
+
+// defaultMaxBodySize bounds how much of a webhook body NewWebhookHandler will read,
+// to stop a malicious or misbehaving sender from exhausting memory.
+const defaultMaxBodySize = 5 << 20 // 5MB
+
+// NewWebhookHandler returns an http.Handler that verifies the X-Hub-Signature-256
+// HMAC against secret, parses the body according to X-GitHub-Event, and routes it to
+// the matching Dispatcher method. maxBodySize <= 0 uses defaultMaxBodySize.
+func NewWebhookHandler(secret string, dispatcher Dispatcher, maxBodySize int64) http.Handler {
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxBodySize
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if int64(len(body)) > maxBodySize {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if !VerifySignature(secret, body, r.Header.Get("X-Hub-Signature-256")) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), deliveryIDKey{}, r.Header.Get("X-GitHub-Delivery"))
+
+		eventType := r.Header.Get("X-GitHub-Event")
+		action := ""
+		var err2 error
+		switch eventType {
+		case EventPullRequest:
+			var event PullRequestEvent
+			if err2 = json.Unmarshal(body, &event); err2 == nil {
+				action = event.Action
+				err2 = dispatcher.HandlePullRequest(ctx, event)
+			}
+		case EventRepository:
+			var event RepositoryEvent
+			if err2 = json.Unmarshal(body, &event); err2 == nil {
+				action = event.Action
+				err2 = dispatcher.HandleRepository(ctx, event)
+			}
+		case EventPush:
+			var event PushEvent
+			if err2 = json.Unmarshal(body, &event); err2 == nil {
+				err2 = dispatcher.HandlePush(ctx, event)
+			}
+		case EventCheckRun:
+			var event CheckRunEvent
+			if err2 = json.Unmarshal(body, &event); err2 == nil {
+				action = event.Action
+				err2 = dispatcher.HandleCheckRun(ctx, event)
+			}
+		case EventInstallation:
+			var event InstallationEvent
+			if err2 = json.Unmarshal(body, &event); err2 == nil {
+				action = event.Action
+				err2 = dispatcher.HandleInstallation(ctx, event)
+			}
+		default:
+			metrics.WebhookEventsTotal.WithLabelValues(eventType, action, "ignored").Inc()
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		if err2 != nil {
+			metrics.WebhookEventsTotal.WithLabelValues(eventType, action, "error").Inc()
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		metrics.WebhookEventsTotal.WithLabelValues(eventType, action, "accepted").Inc()
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
@@ -0,0 +1,70 @@
+// Package metrics registers auto-deployer's Prometheus collectors and exposes
+// the handful of helpers callers use to record them, so instrumentation
+// elsewhere in the codebase doesn't need to touch the prometheus API
+// directly.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	WebhookEventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "autodeployer_webhook_events_total",
+		Help: "Webhook events received, by event type, action, and outcome.",
+	}, []string{"event", "action", "result"})
+
+	PreviewQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "autodeployer_preview_queue_depth",
+		Help: "Number of preview jobs currently pending or running in the queue.",
+	})
+
+	PreviewQueueDroppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "autodeployer_preview_queue_dropped_total",
+		Help: "Preview jobs that failed to enqueue, e.g. because the queue store rejected them.",
+	})
+
+	BuildDurationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "autodeployer_build_duration_seconds",
+		Help:    "Image build duration, by repo and outcome.",
+		Buckets: prometheus.ExponentialBuckets(5, 2, 10),
+	}, []string{"repo", "result"})
+
+	ReconcileDurationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "autodeployer_reconcile_duration_seconds",
+		Help:    "Preview reconcile duration, by operation and outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"op", "result"})
+
+	StaleCleanupDeletedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "autodeployer_stale_cleanup_deleted_total",
+		Help: "Previews deleted by the stale cleanup sweep.",
+	})
+
+	ActivePreviews = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "autodeployer_active_previews",
+		Help: "Previews currently deployed, by namespace.",
+	}, []string{"namespace"})
+)
+
+// result is the canonical label value for a recorded outcome, so callers
+// don't each invent their own "ok"/"success"/"succeeded" spelling.
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+// ObserveBuildDuration records a completed image build's wall-clock time.
+func ObserveBuildDuration(repo string, started time.Time, err error) {
+	BuildDurationSeconds.WithLabelValues(repo, result(err)).Observe(time.Since(started).Seconds())
+}
+
+// ObserveReconcileDuration records a completed reconcile operation's wall-clock time.
+func ObserveReconcileDuration(op string, started time.Time, err error) {
+	ReconcileDurationSeconds.WithLabelValues(op, result(err)).Observe(time.Since(started).Seconds())
+}
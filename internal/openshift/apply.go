@@ -2,86 +2,189 @@ package openshift
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
-	appsv1 "k8s.io/api/apps/v1"
-	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 )
 
-func (c *Client) ApplyDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) (bool, error) {
-	client := c.Kube.AppsV1().Deployments(namespace)
-	existing, err := client.Get(ctx, deployment.Name, metav1.GetOptions{})
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			_, err = client.Create(ctx, deployment, metav1.CreateOptions{})
-			return true, err
-		}
-		return false, err
-	}
+// FieldManager identifies this controller to the API server's server-side
+// apply machinery, so our managed fields can be told apart from a
+// defaulter's or another controller's.
+const FieldManager = "preview-controller"
+
+// annotationCompareOptions lets a rendered manifest opt a resource out of
+// noisy drift reporting for fields the cluster, not this controller, owns.
+const annotationCompareOptions = "preview-controller/compare-options"
+
+// CompareOptionIgnoreExtraneous is the only recognized annotationCompareOptions
+// value today: it excludes fields the cluster commonly mutates out from under
+// a desired manifest (a Service's ClusterIP, a port's NodePort, a Route's
+// host) from Apply's drift computation.
+const CompareOptionIgnoreExtraneous = "IgnoreExtraneous"
 
-	deployment.ResourceVersion = existing.ResourceVersion
-	_, err = client.Update(ctx, deployment, metav1.UpdateOptions{})
-	return false, err
+var ignoreExtraneousFieldPaths = [][]string{
+	{"spec", "clusterIP"},
+	{"spec", "clusterIPs"},
+	{"spec", "host"},
 }
 
-func (c *Client) ApplyService(ctx context.Context, namespace string, service *corev1.Service) (bool, error) {
-	client := c.Kube.CoreV1().Services(namespace)
-	existing, err := client.Get(ctx, service.Name, metav1.GetOptions{})
+// DiffResult is what Apply found when it reconciled a resource against the
+// cluster: whether the server's prior state already matched the desired
+// manifest, and which top-level field names (e.g. "spec", "metadata")
+// differed beforehand.
+type DiffResult struct {
+	Changed       bool
+	ChangedFields []string
+}
+
+// Apply reconciles obj into the cluster using Kubernetes server-side apply: a
+// PATCH with the apply-patch content type, scoped to FieldManager, that
+// creates obj if it doesn't exist yet and otherwise takes ownership of
+// exactly the fields obj sets, quietly coexisting with whatever defaulters
+// and other controllers have set on everything else. Conflicts with another
+// field manager are always resolved in our favor (force=true), since a
+// preview's resources are solely owned by this controller.
+//
+// The returned DiffResult is computed by comparing the object's state before
+// the patch against obj, so reconcile can log what the apply actually
+// changed instead of patching blind every time.
+func (c *Client) Apply(ctx context.Context, gvr schema.GroupVersionResource, namespace string, obj *unstructured.Unstructured) (DiffResult, error) {
+	res := c.Dynamic.Resource(gvr).Namespace(namespace)
+
+	before, err := res.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return DiffResult{}, fmt.Errorf("get %s/%s before apply: %w", gvr.Resource, obj.GetName(), err)
+	}
+
+	data, err := json.Marshal(obj.Object)
 	if err != nil {
-		if apierrors.IsNotFound(err) {
-			_, err = client.Create(ctx, service, metav1.CreateOptions{})
-			return true, err
-		}
-		return false, err
+		return DiffResult{}, fmt.Errorf("marshal %s/%s for apply: %w", gvr.Resource, obj.GetName(), err)
+	}
+
+	force := true
+	if _, err := res.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	}); err != nil {
+		return DiffResult{}, fmt.Errorf("apply %s/%s: %w", gvr.Resource, obj.GetName(), err)
 	}
 
-	service.ResourceVersion = existing.ResourceVersion
-	preserveServiceFields(existing, service)
-	_, err = client.Update(ctx, service, metav1.UpdateOptions{})
-	return false, err
+	if before == nil {
+		return DiffResult{Changed: true, ChangedFields: []string{"(created)"}}, nil
+	}
+	return diffManifest(before, obj), nil
 }
 
-func (c *Client) ApplyRoute(ctx context.Context, namespace string, route *unstructured.Unstructured) (bool, error) {
-	client := c.Dynamic.Resource(RouteGVR).Namespace(namespace)
-	existing, err := client.Get(ctx, route.GetName(), metav1.GetOptions{})
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			_, err = client.Create(ctx, route, metav1.CreateOptions{})
-			return true, err
+// diffManifest reports which top-level fields of desired differ from before.
+// Only top-level fields desired sets are compared (status and top-level fields
+// the server alone populates are skipped), but each is compared as a whole
+// value: a nested field the server defaults inside one of them (e.g. in spec)
+// makes that field count as changed. Managed fields are not consulted. For
+// metadata only labels and annotations are compared. When desired opts into
+// CompareOptionIgnoreExtraneous via
+// annotationCompareOptions, a short list of fields the cluster is known to
+// mutate (ClusterIP, NodePort, Route host) is stripped from both sides first.
+func diffManifest(before, desired *unstructured.Unstructured) DiffResult {
+	ignoreExtraneous := desired.GetAnnotations()[annotationCompareOptions] == CompareOptionIgnoreExtraneous
+
+	var changed []string
+	for field, desiredValue := range desired.Object {
+		if field == "status" || field == "metadata" {
+			continue
+		}
+		beforeValue, ok := before.Object[field]
+		if ignoreExtraneous {
+			beforeValue = stripIgnoredFields(beforeValue, field)
+			desiredValue = stripIgnoredFields(desiredValue, field)
 		}
-		return false, err
+		if !ok || !valuesEqual(beforeValue, desiredValue) {
+			changed = append(changed, field)
+		}
+	}
+
+	if labelsOrAnnotationsChanged(before, desired) {
+		changed = append(changed, "metadata")
 	}
 
-	route.SetResourceVersion(existing.GetResourceVersion())
-	_, err = client.Update(ctx, route, metav1.UpdateOptions{})
-	return false, err
+	return DiffResult{Changed: len(changed) > 0, ChangedFields: changed}
+}
+
+func labelsOrAnnotationsChanged(before, desired *unstructured.Unstructured) bool {
+	return !valuesEqual(before.GetLabels(), desired.GetLabels()) ||
+		!valuesEqual(stripBookkeepingAnnotations(before.GetAnnotations()), stripBookkeepingAnnotations(desired.GetAnnotations()))
 }
 
-func preserveServiceFields(existing *corev1.Service, desired *corev1.Service) {
-	if desired.Spec.ClusterIP == "" {
-		desired.Spec.ClusterIP = existing.Spec.ClusterIP
+// stripBookkeepingAnnotations removes the annotations this controller itself
+// maintains from consideration, since they're expected to differ on every
+// reconcile and aren't evidence of drift a reconcile needs to report.
+func stripBookkeepingAnnotations(annotations map[string]string) map[string]string {
+	if annotations == nil {
+		return nil
 	}
-	if len(desired.Spec.ClusterIPs) == 0 && len(existing.Spec.ClusterIPs) > 0 {
-		desired.Spec.ClusterIPs = existing.Spec.ClusterIPs
+	stripped := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		if k == "preview-controller/last-updated-at" {
+			continue
+		}
+		stripped[k] = v
 	}
-	if desired.Spec.IPFamilies == nil && existing.Spec.IPFamilies != nil {
-		desired.Spec.IPFamilies = existing.Spec.IPFamilies
+	return stripped
+}
+
+func stripIgnoredFields(value interface{}, topField string) interface{} {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return value
 	}
-	if desired.Spec.IPFamilyPolicy == nil && existing.Spec.IPFamilyPolicy != nil {
-		desired.Spec.IPFamilyPolicy = existing.Spec.IPFamilyPolicy
+	cloned := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		cloned[k] = v
 	}
-	for i := range desired.Spec.Ports {
-		desired.Spec.Ports[i].NodePort = existingNodePort(existing, desired.Spec.Ports[i].Name)
+	for _, path := range ignoreExtraneousFieldPaths {
+		if path[0] != topField {
+			continue
+		}
+		delete(cloned, path[1])
 	}
+	if ports, ok := cloned["ports"].([]interface{}); ok {
+		cloned["ports"] = stripPortNodePorts(ports)
+	}
+	return cloned
 }
 
-func existingNodePort(existing *corev1.Service, name string) int32 {
-	for _, port := range existing.Spec.Ports {
-		if port.Name == name {
-			return port.NodePort
+func stripPortNodePorts(ports []interface{}) []interface{} {
+	stripped := make([]interface{}, len(ports))
+	for i, p := range ports {
+		port, ok := p.(map[string]interface{})
+		if !ok {
+			stripped[i] = p
+			continue
+		}
+		cloned := make(map[string]interface{}, len(port))
+		for k, v := range port {
+			if k == "nodePort" {
+				continue
+			}
+			cloned[k] = v
 		}
+		stripped[i] = cloned
+	}
+	return stripped
+}
+
+func valuesEqual(a, b interface{}) bool {
+	aJSON, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bJSON, err := json.Marshal(b)
+	if err != nil {
+		return false
 	}
-	return 0
+	return string(aJSON) == string(bJSON)
 }
@@ -1,6 +1,7 @@
 package openshift
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -33,6 +34,16 @@ func NewClientFromEnv() (*Client, error) {
 	return &Client{Kube: kubeClient, Dynamic: dynClient}, nil
 }
 
+// Ping probes the configured cluster's API server health endpoint. It's meant
+// for wiring into a readiness probe: a nil error means the client can
+// currently reach and authenticate against the API server.
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.Kube.Discovery().RESTClient().Get().AbsPath("/healthz").Do(ctx).Error(); err != nil {
+		return fmt.Errorf("openshift api health check: %w", err)
+	}
+	return nil
+}
+
 func configFromEnv() (*rest.Config, error) {
 	apiURL := os.Getenv("OPENSHIFT_API_URL")
 	if apiURL == "" {
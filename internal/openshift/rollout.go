@@ -0,0 +1,174 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// rolloutPollInterval is how often WaitForDeploymentReady re-checks the
+// Deployment's status while waiting for a rollout to finish.
+const rolloutPollInterval = 2 * time.Second
+
+// lastPodEventsLimit bounds how many events WaitForDeploymentReady attaches
+// to a failed RolloutResult, newest first.
+const lastPodEventsLimit = 5
+
+// RolloutPhase classifies the outcome of WaitForDeploymentReady.
+type RolloutPhase string
+
+const (
+	RolloutReady   RolloutPhase = "Ready"
+	RolloutFailed  RolloutPhase = "Failed"
+	RolloutTimeout RolloutPhase = "Timeout"
+)
+
+// RolloutResult is the outcome of waiting for a Deployment's rollout to
+// finish. Reason and LastPodEvents are only populated when Phase isn't
+// RolloutReady, to help a PR comment or log line explain why a preview isn't
+// up without the caller needing to go dig through the cluster.
+type RolloutResult struct {
+	Phase         RolloutPhase
+	Reason        string
+	LastPodEvents []string
+}
+
+// WaitForDeploymentReady polls the named Deployment until its rollout has
+// finished — ObservedGeneration caught up, UpdatedReplicas and
+// AvailableReplicas both matching the desired Replicas, and no
+// ProgressDeadlineExceeded condition — or timeout elapses, whichever comes
+// first. On anything other than RolloutReady it gathers the selector's most
+// recent events and the latest container's Waiting reason, mirroring the
+// diagnostics Helm's pkg/kube/wait.go surfaces for a stuck rollout.
+func (c *Client) WaitForDeploymentReady(ctx context.Context, namespace, name string, timeout time.Duration) (RolloutResult, error) {
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(rolloutPollInterval)
+	defer ticker.Stop()
+
+	for {
+		deployment, err := c.Kube.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return RolloutResult{}, fmt.Errorf("get deployment %s/%s: %w", namespace, name, err)
+		}
+
+		if reason, failed := progressDeadlineExceeded(deployment); failed {
+			return c.diagnoseRollout(ctx, namespace, deployment, RolloutFailed, reason)
+		}
+
+		if rolloutComplete(deployment) {
+			return RolloutResult{Phase: RolloutReady}, nil
+		}
+
+		if time.Now().After(deadline) {
+			return c.diagnoseRollout(ctx, namespace, deployment, RolloutTimeout,
+				fmt.Sprintf("rollout did not finish within %s", timeout))
+		}
+
+		select {
+		case <-ctx.Done():
+			return RolloutResult{}, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func rolloutComplete(d *appsv1.Deployment) bool {
+	spec := d.Spec
+	status := d.Status
+	desired := int32(1)
+	if spec.Replicas != nil {
+		desired = *spec.Replicas
+	}
+	return status.ObservedGeneration >= d.Generation &&
+		status.UpdatedReplicas == desired &&
+		status.AvailableReplicas == desired
+}
+
+func progressDeadlineExceeded(d *appsv1.Deployment) (string, bool) {
+	for _, cond := range d.Status.Conditions {
+		if cond.Type == "Progressing" && cond.Reason == "ProgressDeadlineExceeded" {
+			return cond.Message, true
+		}
+	}
+	return "", false
+}
+
+func (c *Client) diagnoseRollout(ctx context.Context, namespace string, deployment *appsv1.Deployment, phase RolloutPhase, reason string) (RolloutResult, error) {
+	selector := labelSelectorFromMatchLabels(deployment.Spec.Selector.MatchLabels)
+
+	events, err := c.lastPodEvents(ctx, namespace, selector)
+	if err != nil {
+		return RolloutResult{}, fmt.Errorf("gather rollout diagnostics: %w", err)
+	}
+
+	if containerReason := c.latestContainerWaitingReason(ctx, namespace, selector); containerReason != "" {
+		if reason == "" {
+			reason = containerReason
+		} else {
+			reason = reason + "; " + containerReason
+		}
+	}
+
+	return RolloutResult{Phase: phase, Reason: reason, LastPodEvents: events}, nil
+}
+
+// lastPodEvents returns the most recent lastPodEventsLimit events involving
+// pods matching selector, newest first.
+func (c *Client) lastPodEvents(ctx context.Context, namespace, selector string) ([]string, error) {
+	pods, err := c.Kube.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("list pods: %w", err)
+	}
+
+	var all []corev1.Event
+	for _, pod := range pods.Items {
+		events, err := c.Kube.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+			FieldSelector: fmt.Sprintf("involvedObject.name=%s,involvedObject.namespace=%s", pod.Name, namespace),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("list events for pod %s: %w", pod.Name, err)
+		}
+		all = append(all, events.Items...)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].LastTimestamp.After(all[j].LastTimestamp.Time)
+	})
+	if len(all) > lastPodEventsLimit {
+		all = all[:lastPodEventsLimit]
+	}
+
+	formatted := make([]string, 0, len(all))
+	for _, event := range all {
+		formatted = append(formatted, fmt.Sprintf("%s %s: %s", event.Reason, event.InvolvedObject.Name, event.Message))
+	}
+	return formatted, nil
+}
+
+// latestContainerWaitingReason returns the Waiting reason/message of the
+// first container in the Waiting state it finds among pods matching selector,
+// or "" if no container is waiting (or there are no pods yet).
+func (c *Client) latestContainerWaitingReason(ctx context.Context, namespace, selector string) string {
+	pods, err := c.Kube.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return ""
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting != nil {
+				return fmt.Sprintf("%s: %s: %s", pod.Name, status.State.Waiting.Reason, status.State.Waiting.Message)
+			}
+		}
+	}
+	return ""
+}
+
+func labelSelectorFromMatchLabels(matchLabels map[string]string) string {
+	return labels.SelectorFromSet(matchLabels).String()
+}
@@ -0,0 +1,30 @@
+package queue
+
+import "time"
+
+// State is the lifecycle stage of a queued job.
+type State string
+
+const (
+	StatePending State = "pending"
+	StateRunning State = "running"
+	StateDone    State = "done"
+	StateFailed  State = "failed"
+)
+
+// Job is a persisted unit of work. Payload is an opaque, caller-defined blob
+// (cmd/preview-controller stores a JSON-encoded previewJob in it); the queue
+// package itself doesn't know or care what it contains.
+type Job struct {
+	ID             uint64    `json:"id"`
+	Payload        []byte    `json:"payload"`
+	State          State     `json:"state"`
+	Attempts       int       `json:"attempts"`
+	MaxAttempts    int       `json:"maxAttempts"`
+	LeaseOwner     string    `json:"leaseOwner,omitempty"`
+	LeaseExpiresAt time.Time `json:"leaseExpiresAt,omitempty"`
+	NextAttemptAt  time.Time `json:"nextAttemptAt,omitempty"`
+	LastError      string    `json:"lastError,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
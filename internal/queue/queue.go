@@ -0,0 +1,305 @@
+// Package queue is a small embedded-store work queue: jobs persist across
+// restarts, a worker leases one at a time and renews that lease while it
+// works, and a lease that isn't renewed in time is up for grabs again. That's
+// what lets a crash mid-build lose at most a lease window's worth of work
+// instead of the job outright, which the old purely in-memory
+// `chan previewJob` in cmd/preview-controller couldn't offer.
+package queue
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var jobsBucket = []byte("jobs")
+
+// Options configures a Queue. MaxAttempts, BackoffBase, and BackoffMax fall
+// back to sane defaults when left zero.
+type Options struct {
+	Path        string
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.BackoffBase <= 0 {
+		o.BackoffBase = 30 * time.Second
+	}
+	if o.BackoffMax <= 0 {
+		o.BackoffMax = 30 * time.Minute
+	}
+	return o
+}
+
+// Queue is a durable FIFO of Jobs backed by a single bbolt database file.
+type Queue struct {
+	db   *bbolt.DB
+	opts Options
+}
+
+// Open opens (creating if necessary) the bbolt database at opts.Path.
+func Open(opts Options) (*Queue, error) {
+	opts = opts.withDefaults()
+	if opts.Path == "" {
+		return nil, fmt.Errorf("queue: path is required")
+	}
+
+	db, err := bbolt.Open(opts.Path, 0o600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open queue db: %w", err)
+	}
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(jobsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create jobs bucket: %w", err)
+	}
+
+	return &Queue{db: db, opts: opts}, nil
+}
+
+func (q *Queue) Close() error {
+	return q.db.Close()
+}
+
+// Enqueue persists a new pending job wrapping payload and returns its ID.
+func (q *Queue) Enqueue(payload []byte) (uint64, error) {
+	var id uint64
+	err := q.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		id = seq
+
+		now := time.Now().UTC()
+		return putJob(b, Job{
+			ID:          id,
+			Payload:     payload,
+			State:       StatePending,
+			MaxAttempts: q.opts.MaxAttempts,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	})
+	return id, err
+}
+
+// Lease claims the oldest job that's either pending and due (NextAttemptAt not
+// in the future) or running with an expired lease -- the crash-recovery case,
+// since a worker that died mid-job never called Complete/Fail to clear it. It
+// marks the claimed job running under workerID for leaseDuration and returns
+// it, or returns a nil Job and nil error when nothing is claimable.
+func (q *Queue) Lease(workerID string, leaseDuration time.Duration) (*Job, error) {
+	var claimed *Job
+	now := time.Now().UTC()
+
+	err := q.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var job Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return fmt.Errorf("decode job: %w", err)
+			}
+
+			claimable := (job.State == StatePending && !job.NextAttemptAt.After(now)) ||
+				(job.State == StateRunning && job.LeaseExpiresAt.Before(now))
+			if !claimable {
+				continue
+			}
+
+			job.State = StateRunning
+			job.Attempts++
+			job.LeaseOwner = workerID
+			job.LeaseExpiresAt = now.Add(leaseDuration)
+			job.UpdatedAt = now
+			if err := putJob(b, job); err != nil {
+				return err
+			}
+			claimed = &job
+			return nil
+		}
+		return nil
+	})
+
+	return claimed, err
+}
+
+// Renew extends id's lease by leaseDuration, provided workerID still holds it.
+// A worker calls this periodically (see startLeaseRenewal in
+// cmd/preview-controller) while a job is in flight so a long build doesn't
+// outlive its own lease and get reclaimed out from under it.
+func (q *Queue) Renew(id uint64, workerID string, leaseDuration time.Duration) error {
+	return q.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		job, err := getJob(b, id)
+		if err != nil {
+			return err
+		}
+		if job.LeaseOwner != workerID {
+			return fmt.Errorf("queue: lease for job %d is not held by %q", id, workerID)
+		}
+		job.LeaseExpiresAt = time.Now().UTC().Add(leaseDuration)
+		job.UpdatedAt = time.Now().UTC()
+		return putJob(b, job)
+	})
+}
+
+// Complete marks id done.
+func (q *Queue) Complete(id uint64) error {
+	return q.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		job, err := getJob(b, id)
+		if err != nil {
+			return err
+		}
+		job.State = StateDone
+		job.LeaseOwner = ""
+		job.UpdatedAt = time.Now().UTC()
+		return putJob(b, job)
+	})
+}
+
+// Fail records a failed attempt against id. Once Attempts has reached
+// MaxAttempts the job is marked failed for good; otherwise it goes back to
+// pending with NextAttemptAt pushed out by an exponentially growing backoff.
+func (q *Queue) Fail(id uint64, cause error) error {
+	return q.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		job, err := getJob(b, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		if cause != nil {
+			job.LastError = cause.Error()
+		}
+		job.LeaseOwner = ""
+		job.UpdatedAt = now
+
+		if job.Attempts >= job.MaxAttempts {
+			job.State = StateFailed
+		} else {
+			job.State = StatePending
+			job.NextAttemptAt = now.Add(backoff(q.opts.BackoffBase, q.opts.BackoffMax, job.Attempts))
+		}
+		return putJob(b, job)
+	})
+}
+
+func backoff(base, max time.Duration, attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := base * time.Duration(uint64(1)<<uint(attempt-1))
+	if d <= 0 || d > max {
+		return max
+	}
+	return d
+}
+
+// Stats is the queue depth and per-state counts served at GET /queue. Depth
+// only counts Pending and Running jobs -- the actual backlog -- since Done and
+// Failed jobs stick around (until Prune clears them out) for history/debugging
+// and aren't work still to be done.
+type Stats struct {
+	Depth  int           `json:"depth"`
+	Counts map[State]int `json:"counts"`
+}
+
+func (q *Queue) Stats() (Stats, error) {
+	stats := Stats{Counts: map[State]int{
+		StatePending: 0,
+		StateRunning: 0,
+		StateDone:    0,
+		StateFailed:  0,
+	}}
+
+	err := q.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		return b.ForEach(func(_, v []byte) error {
+			var job Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return err
+			}
+			stats.Counts[job.State]++
+			if job.State == StatePending || job.State == StateRunning {
+				stats.Depth++
+			}
+			return nil
+		})
+	})
+	return stats, err
+}
+
+// Prune deletes every Done or Failed job last updated before olderThan,
+// returning how many were removed. Terminal jobs are kept around for history
+// and debugging, but without pruning they'd accumulate forever, bloating the
+// store and slowing every Lease's full bucket scan. A worker never needs a
+// terminal job again once its outcome has been reported, so it's always safe
+// to drop them once they're old enough.
+func (q *Queue) Prune(olderThan time.Duration) (int, error) {
+	cutoff := time.Now().UTC().Add(-olderThan)
+	removed := 0
+
+	err := q.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		c := b.Cursor()
+		var staleKeys [][]byte
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var job Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return fmt.Errorf("decode job: %w", err)
+			}
+			if (job.State == StateDone || job.State == StateFailed) && job.UpdatedAt.Before(cutoff) {
+				staleKeys = append(staleKeys, append([]byte(nil), k...))
+			}
+		}
+		for _, k := range staleKeys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	return removed, err
+}
+
+func putJob(b *bbolt.Bucket, job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return b.Put(itob(job.ID), data)
+}
+
+func getJob(b *bbolt.Bucket, id uint64) (Job, error) {
+	data := b.Get(itob(id))
+	if data == nil {
+		return Job{}, fmt.Errorf("queue: job %d not found", id)
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return Job{}, err
+	}
+	return job, nil
+}
+
+func itob(id uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, id)
+	return b
+}
@@ -13,6 +13,15 @@ type EnvConfig struct {
 	ImageTemplate string
 	TagStrategy   string
 	DefaultPort   int32
+
+	// Builder selects the image build backend (build.Config.Builder):
+	// "docker", "buildx", "buildah", or "kaniko". Empty falls back to
+	// IMAGE_BUILD_USE_BUILDX: buildx when it is true (the default), plain
+	// docker otherwise.
+	Builder string
+
+	// NamespacePolicy is only enforced in per-pr namespace mode; see EnsureNamespace.
+	NamespacePolicy NamespacePolicy
 }
 
 func LoadEnvConfig() (EnvConfig, error) {
@@ -23,6 +32,7 @@ func LoadEnvConfig() (EnvConfig, error) {
 		ImageTemplate: os.Getenv("IMAGE_REF_TEMPLATE"),
 		TagStrategy:   os.Getenv("IMAGE_TAG_STRATEGY"),
 		DefaultPort:   8080,
+		Builder:       os.Getenv("BUILDER"),
 	}
 
 	if cfg.NamespaceMode == "" {
@@ -46,5 +56,15 @@ func LoadEnvConfig() (EnvConfig, error) {
 		cfg.DefaultPort = int32(parsed)
 	}
 
+	cfg.NamespacePolicy = NamespacePolicy{
+		MaxCPU:               os.Getenv("PREVIEW_NS_MAX_CPU"),
+		MaxMemory:            os.Getenv("PREVIEW_NS_MAX_MEMORY"),
+		MaxPods:              os.Getenv("PREVIEW_NS_MAX_PODS"),
+		DefaultCPURequest:    os.Getenv("PREVIEW_NS_DEFAULT_CPU_REQUEST"),
+		DefaultCPULimit:      os.Getenv("PREVIEW_NS_DEFAULT_CPU_LIMIT"),
+		DefaultMemoryRequest: os.Getenv("PREVIEW_NS_DEFAULT_MEMORY_REQUEST"),
+		DefaultMemoryLimit:   os.Getenv("PREVIEW_NS_DEFAULT_MEMORY_LIMIT"),
+	}
+
 	return cfg, nil
 }
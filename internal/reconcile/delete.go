@@ -9,17 +9,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// DeletePreview tears down a preview's resources. It's a special case of Sync with
+// an empty desired set: everything tracked and labeled for this PR is "Extra" and
+// gets pruned.
 func DeletePreview(ctx context.Context, client *openshift.Client, cfg PreviewConfig, namespaceMode string) error {
-	selector := labelSelector(cfg)
-
-	if err := deleteDeployments(ctx, client, cfg.Namespace, selector); err != nil {
-		return fmt.Errorf("delete deployments: %w", err)
-	}
-	if err := deleteServices(ctx, client, cfg.Namespace, selector); err != nil {
-		return fmt.Errorf("delete services: %w", err)
-	}
-	if err := deleteRoutes(ctx, client, cfg.Namespace, selector); err != nil {
-		return fmt.Errorf("delete routes: %w", err)
+	if _, err := Sync(ctx, client, cfg, nil); err != nil {
+		return fmt.Errorf("sync empty desired set: %w", err)
 	}
 
 	if namespaceMode == "per-pr" {
@@ -35,45 +30,14 @@ func labelSelector(cfg PreviewConfig) string {
 	return fmt.Sprintf("preview-controller/preview=true,preview-controller/pr=%d,preview-controller/repo=%s", cfg.PRNumber, sanitizeLabelValue(cfg.RepoFullName))
 }
 
-func NamespaceResource(name string) *corev1.Namespace {
-	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
-}
-
-func deleteDeployments(ctx context.Context, client *openshift.Client, namespace, selector string) error {
-	deployments, err := client.Kube.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
-	if err != nil {
-		return err
-	}
-	for _, item := range deployments.Items {
-		if err := client.Kube.AppsV1().Deployments(namespace).Delete(ctx, item.Name, metav1.DeleteOptions{}); err != nil {
-			return err
-		}
-	}
-	return nil
+// PreviewSelector returns the label selector that matches every resource
+// belonging to repoFullName's PR prNumber, for callers outside this package
+// that need to look a preview up by repo+PR alone (e.g. the log-tail HTTP
+// handler), without building a full PreviewConfig.
+func PreviewSelector(repoFullName string, prNumber int) string {
+	return labelSelector(PreviewConfig{RepoFullName: repoFullName, PRNumber: prNumber})
 }
 
-func deleteServices(ctx context.Context, client *openshift.Client, namespace, selector string) error {
-	services, err := client.Kube.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
-	if err != nil {
-		return err
-	}
-	for _, item := range services.Items {
-		if err := client.Kube.CoreV1().Services(namespace).Delete(ctx, item.Name, metav1.DeleteOptions{}); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func deleteRoutes(ctx context.Context, client *openshift.Client, namespace, selector string) error {
-	routes, err := client.Dynamic.Resource(openshift.RouteGVR).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
-	if err != nil {
-		return err
-	}
-	for _, item := range routes.Items {
-		if err := client.Dynamic.Resource(openshift.RouteGVR).Namespace(namespace).Delete(ctx, item.GetName(), metav1.DeleteOptions{}); err != nil {
-			return err
-		}
-	}
-	return nil
+func NamespaceResource(name string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
 }
@@ -11,6 +11,39 @@ type AppMapping struct {
 	ContainerPort int32             `json:"containerPort"`
 	RoutePath     string            `json:"routePath"`
 	Env           map[string]string `json:"env"`
+
+	// ChartPath, KustomizeDir, and TemplateDir are optional paths (resolved
+	// against the controller's working directory; the PR's repo is not cloned
+	// for rendering) that opt an app into rendering its preview from a Helm
+	// chart, a Kustomize overlay, or a directory of Go-templated manifests
+	// instead of the built-in Deployment/Service/Route templates. At most one
+	// should be set; ChartPath takes precedence, then KustomizeDir, then
+	// TemplateDir.
+	ChartPath       string                 `json:"chartPath,omitempty"`
+	ChartValuesFile string                 `json:"chartValuesFile,omitempty"`
+	KustomizeDir    string                 `json:"kustomizeDir,omitempty"`
+	TemplateDir     string                 `json:"templateDir,omitempty"`
+	TemplateValues  map[string]interface{} `json:"templateValues,omitempty"`
+
+	// BuildArgs is passed through to the app's image build as --build-arg
+	// (or the selected backend's equivalent). Unlike Secrets, these are
+	// ordinary values, so they're safe to keep in the mapping file.
+	BuildArgs map[string]string `json:"buildArgs,omitempty"`
+}
+
+// Renderer returns the Renderer this mapping selects, falling back to the built-in
+// template renderer when no chart, overlay, or template dir is configured.
+func (m AppMapping) Renderer() Renderer {
+	switch {
+	case m.ChartPath != "":
+		return HelmRenderer{ChartPath: m.ChartPath, ValuesFile: m.ChartValuesFile}
+	case m.KustomizeDir != "":
+		return KustomizeRenderer{OverlayDir: m.KustomizeDir}
+	case m.TemplateDir != "":
+		return TemplateRenderer{Dir: m.TemplateDir, Values: m.TemplateValues}
+	default:
+		return BuiltinRenderer{}
+	}
 }
 
 type MappingFile map[string]AppMapping
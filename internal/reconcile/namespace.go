@@ -0,0 +1,252 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+
+	"auto-deployer/internal/openshift"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var networkPolicyGVR = schema.GroupVersionResource{
+	Group:    "networking.k8s.io",
+	Version:  "v1",
+	Resource: "networkpolicies",
+}
+
+// NamespacePolicy bounds the blast radius of a per-pr preview namespace: a
+// ResourceQuota caps aggregate consumption, a LimitRange fills in defaults for
+// containers that don't set their own, and a NetworkPolicy denies ingress from
+// other namespaces by default while still letting the OpenShift router reach pods.
+type NamespacePolicy struct {
+	MaxCPU    string
+	MaxMemory string
+	MaxPods   string
+
+	DefaultCPURequest    string
+	DefaultCPULimit      string
+	DefaultMemoryRequest string
+	DefaultMemoryLimit   string
+}
+
+func (p NamespacePolicy) isZero() bool {
+	return p == NamespacePolicy{}
+}
+
+// EnsureNamespace creates cfg.Namespace if it doesn't exist and, when cfg.NamespacePolicy
+// is set, idempotently applies the ResourceQuota/LimitRange/NetworkPolicy that keep a
+// per-pr namespace from starving the rest of the cluster. It's a no-op for shared
+// namespace modes, where quotas belong to whoever owns the namespace already.
+func EnsureNamespace(ctx context.Context, client *openshift.Client, cfg PreviewConfig, namespaceMode string) error {
+	if namespaceMode != "per-pr" {
+		return nil
+	}
+
+	if err := ensureNamespaceExists(ctx, client, cfg.Namespace); err != nil {
+		return fmt.Errorf("ensure namespace: %w", err)
+	}
+
+	if cfg.NamespacePolicy.isZero() {
+		return nil
+	}
+
+	if err := applyResourceQuota(ctx, client, cfg); err != nil {
+		return fmt.Errorf("apply resource quota: %w", err)
+	}
+	if err := applyLimitRange(ctx, client, cfg); err != nil {
+		return fmt.Errorf("apply limit range: %w", err)
+	}
+	if err := applyNetworkPolicy(ctx, client, cfg); err != nil {
+		return fmt.Errorf("apply network policy: %w", err)
+	}
+	return nil
+}
+
+func ensureNamespaceExists(ctx context.Context, client *openshift.Client, name string) error {
+	namespaces := client.Kube.CoreV1().Namespaces()
+	if _, err := namespaces.Get(ctx, name, metav1.GetOptions{}); err == nil {
+		return nil
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	_, err := namespaces.Create(ctx, NamespaceResource(name), metav1.CreateOptions{})
+	if err != nil && apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// parseQuantity parses value as a resource.Quantity, returning an error
+// (rather than panicking, like resource.MustParse does) tagged with which
+// NamespacePolicy field it came from, so a typo in one app's quota config
+// surfaces as a failed reconcile instead of crashing the whole process.
+func parseQuantity(field, value string) (resource.Quantity, error) {
+	qty, err := resource.ParseQuantity(value)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("NamespacePolicy.%s=%q: %w", field, value, err)
+	}
+	return qty, nil
+}
+
+func applyResourceQuota(ctx context.Context, client *openshift.Client, cfg PreviewConfig) error {
+	name := ResourcePrefix(cfg.AppName, cfg.PRNumber) + "-quota"
+	hard := corev1.ResourceList{}
+	if cfg.NamespacePolicy.MaxCPU != "" {
+		qty, err := parseQuantity("MaxCPU", cfg.NamespacePolicy.MaxCPU)
+		if err != nil {
+			return err
+		}
+		hard[corev1.ResourceLimitsCPU] = qty
+	}
+	if cfg.NamespacePolicy.MaxMemory != "" {
+		qty, err := parseQuantity("MaxMemory", cfg.NamespacePolicy.MaxMemory)
+		if err != nil {
+			return err
+		}
+		hard[corev1.ResourceLimitsMemory] = qty
+	}
+	if cfg.NamespacePolicy.MaxPods != "" {
+		qty, err := parseQuantity("MaxPods", cfg.NamespacePolicy.MaxPods)
+		if err != nil {
+			return err
+		}
+		hard[corev1.ResourcePods] = qty
+	}
+
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cfg.Namespace, Labels: Labels(cfg)},
+		Spec:       corev1.ResourceQuotaSpec{Hard: hard},
+	}
+
+	quotas := client.Kube.CoreV1().ResourceQuotas(cfg.Namespace)
+	existing, err := quotas.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			_, err = quotas.Create(ctx, quota, metav1.CreateOptions{})
+			return err
+		}
+		return err
+	}
+	quota.ResourceVersion = existing.ResourceVersion
+	_, err = quotas.Update(ctx, quota, metav1.UpdateOptions{})
+	return err
+}
+
+func applyLimitRange(ctx context.Context, client *openshift.Client, cfg PreviewConfig) error {
+	name := ResourcePrefix(cfg.AppName, cfg.PRNumber) + "-limits"
+	policy := cfg.NamespacePolicy
+
+	defaults := corev1.ResourceList{}
+	defaultRequests := corev1.ResourceList{}
+	if policy.DefaultCPULimit != "" {
+		qty, err := parseQuantity("DefaultCPULimit", policy.DefaultCPULimit)
+		if err != nil {
+			return err
+		}
+		defaults[corev1.ResourceCPU] = qty
+	}
+	if policy.DefaultMemoryLimit != "" {
+		qty, err := parseQuantity("DefaultMemoryLimit", policy.DefaultMemoryLimit)
+		if err != nil {
+			return err
+		}
+		defaults[corev1.ResourceMemory] = qty
+	}
+	if policy.DefaultCPURequest != "" {
+		qty, err := parseQuantity("DefaultCPURequest", policy.DefaultCPURequest)
+		if err != nil {
+			return err
+		}
+		defaultRequests[corev1.ResourceCPU] = qty
+	}
+	if policy.DefaultMemoryRequest != "" {
+		qty, err := parseQuantity("DefaultMemoryRequest", policy.DefaultMemoryRequest)
+		if err != nil {
+			return err
+		}
+		defaultRequests[corev1.ResourceMemory] = qty
+	}
+
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cfg.Namespace, Labels: Labels(cfg)},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				{
+					Type:           corev1.LimitTypeContainer,
+					Default:        defaults,
+					DefaultRequest: defaultRequests,
+				},
+			},
+		},
+	}
+
+	limitRanges := client.Kube.CoreV1().LimitRanges(cfg.Namespace)
+	existing, err := limitRanges.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			_, err = limitRanges.Create(ctx, limitRange, metav1.CreateOptions{})
+			return err
+		}
+		return err
+	}
+	limitRange.ResourceVersion = existing.ResourceVersion
+	_, err = limitRanges.Update(ctx, limitRange, metav1.UpdateOptions{})
+	return err
+}
+
+// applyNetworkPolicy denies ingress from other namespaces by default while still
+// allowing traffic from the OpenShift router, identified by the well-known
+// network.openshift.io/policy-group=ingress namespace label.
+func applyNetworkPolicy(ctx context.Context, client *openshift.Client, cfg PreviewConfig) error {
+	name := ResourcePrefix(cfg.AppName, cfg.PRNumber) + "-deny-other-namespaces"
+	networkPolicy := map[string]interface{}{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "NetworkPolicy",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": cfg.Namespace,
+			"labels":    Labels(cfg),
+		},
+		"spec": map[string]interface{}{
+			"podSelector": map[string]interface{}{},
+			"policyTypes": []interface{}{"Ingress"},
+			"ingress": []interface{}{
+				map[string]interface{}{
+					"from": []interface{}{
+						map[string]interface{}{
+							"namespaceSelector": map[string]interface{}{
+								"matchLabels": map[string]interface{}{
+									"network.openshift.io/policy-group": "ingress",
+								},
+							},
+						},
+						map[string]interface{}{
+							"podSelector": map[string]interface{}{},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	res := client.Dynamic.Resource(networkPolicyGVR).Namespace(cfg.Namespace)
+	obj := &unstructured.Unstructured{Object: networkPolicy}
+
+	existing, err := res.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			_, err = res.Create(ctx, obj, metav1.CreateOptions{})
+			return err
+		}
+		return err
+	}
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	_, err = res.Update(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
@@ -8,45 +8,78 @@ import (
 	"auto-deployer/internal/openshift"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
-func UpsertPreview(ctx context.Context, client *openshift.Client, cfg PreviewConfig) error {
+// UpsertPreview reconciles a preview using the built-in Deployment/Service/Route
+// templates. Use UpsertPreviewWithRenderer to source manifests from a Helm chart or
+// Kustomize overlay instead.
+func UpsertPreview(ctx context.Context, client *openshift.Client, cfg PreviewConfig, namespaceMode string) error {
+	return UpsertPreviewWithRenderer(ctx, client, cfg, namespaceMode, BuiltinRenderer{})
+}
+
+func UpsertPreviewWithRenderer(ctx context.Context, client *openshift.Client, cfg PreviewConfig, namespaceMode string, renderer Renderer) error {
+	if err := EnsureNamespace(ctx, client, cfg, namespaceMode); err != nil {
+		return fmt.Errorf("ensure namespace: %w", err)
+	}
+
 	now := time.Now().UTC()
 
-	createdAt, err := existingCreatedAt(ctx, client, cfg)
+	createdAt, _, err := existingTimestamps(ctx, client, cfg)
 	if err != nil {
 		return err
 	}
 
-	annotations := Annotations(cfg, now, createdAt)
-	deployment := BuildDeployment(cfg, annotations)
-	service := BuildService(cfg, annotations)
-	route := BuildRoute(cfg, annotations)
-
-	if _, err := client.ApplyDeployment(ctx, cfg.Namespace, deployment); err != nil {
-		return fmt.Errorf("apply deployment: %w", err)
-	}
-	if _, err := client.ApplyService(ctx, cfg.Namespace, service); err != nil {
-		return fmt.Errorf("apply service: %w", err)
+	// last-updated-at is itself excluded from diff comparison (see
+	// stripBookkeepingAnnotations in openshift.diffManifest), so a single sync
+	// with the real timestamp reports the same Diffs a stale-timestamp probe
+	// sync would, without doubling List+Apply traffic across every tracked GVR.
+	desired, err := renderer.Render(ctx, cfg, Annotations(cfg, now, createdAt, now.Format(time.RFC3339)))
+	if err != nil {
+		return fmt.Errorf("render desired resources: %w", err)
 	}
-	if _, err := client.ApplyRoute(ctx, cfg.Namespace, route); err != nil {
-		return fmt.Errorf("apply route: %w", err)
+
+	if _, err := Sync(ctx, client, cfg, desired); err != nil {
+		return fmt.Errorf("sync preview: %w", err)
 	}
 
 	return nil
 }
 
-func existingCreatedAt(ctx context.Context, client *openshift.Client, cfg PreviewConfig) (string, error) {
+// desiredResources builds the declarative set of resources the built-in renderer
+// produces: Deployment, Service, and Route.
+func desiredResources(cfg PreviewConfig, annotations map[string]string) ([]*unstructured.Unstructured, error) {
+	typed := []interface{}{
+		BuildDeployment(cfg, annotations),
+		BuildService(cfg, annotations),
+		BuildRoute(cfg, annotations),
+	}
+
+	desired := make([]*unstructured.Unstructured, 0, len(typed))
+	for _, obj := range typed {
+		u, err := toUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+		desired = append(desired, u)
+	}
+	return desired, nil
+}
+
+// existingTimestamps looks up the created-at and last-updated-at annotations
+// of a preview's Deployment, if it already exists. Both are empty for a
+// preview that hasn't been reconciled yet.
+func existingTimestamps(ctx context.Context, client *openshift.Client, cfg PreviewConfig) (createdAt, lastUpdatedAt string, err error) {
 	name := ResourcePrefix(cfg.AppName, cfg.PRNumber)
 	deployment, err := client.Kube.AppsV1().Deployments(cfg.Namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		if apierrors.IsNotFound(err) {
-			return "", nil
+			return "", "", nil
 		}
-		return "", err
+		return "", "", err
 	}
 	if deployment.Annotations == nil {
-		return "", nil
+		return "", "", nil
 	}
-	return deployment.Annotations["preview-controller/created-at"], nil
+	return deployment.Annotations["preview-controller/created-at"], deployment.Annotations["preview-controller/last-updated-at"], nil
 }
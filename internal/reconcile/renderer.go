@@ -0,0 +1,239 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// Renderer produces the desired manifests for a preview. The built-in renderer keeps
+// today's hardcoded Deployment/Service/Route; Helm and Kustomize renderers let teams
+// reuse their production manifests instead.
+type Renderer interface {
+	Render(ctx context.Context, cfg PreviewConfig, annotations map[string]string) ([]*unstructured.Unstructured, error)
+}
+
+// BuiltinRenderer renders the programmatic Deployment/Service/Route this controller
+// has always produced.
+type BuiltinRenderer struct{}
+
+func (BuiltinRenderer) Render(_ context.Context, cfg PreviewConfig, annotations map[string]string) ([]*unstructured.Unstructured, error) {
+	return desiredResources(cfg, annotations)
+}
+
+// HelmRenderer runs `helm template` against the chart at ChartPath, resolved
+// against the controller's working directory (the PR's repo is not cloned for
+// rendering), overriding the image tag and route host with PR-specific values.
+type HelmRenderer struct {
+	ChartPath  string
+	ValuesFile string
+}
+
+func (r HelmRenderer) Render(ctx context.Context, cfg PreviewConfig, annotations map[string]string) ([]*unstructured.Unstructured, error) {
+	if r.ChartPath == "" {
+		return nil, fmt.Errorf("helm renderer: chart path is empty")
+	}
+
+	releaseName := ResourcePrefix(cfg.AppName, cfg.PRNumber)
+	args := []string{"template", releaseName, r.ChartPath,
+		"--namespace", cfg.Namespace,
+		"--set", "image.repository=" + imageRepository(cfg.ImageRef),
+		"--set", "image.tag=" + imageTagOf(cfg.ImageRef),
+		"--set", "route.host=" + cfg.RouteHost,
+	}
+	if r.ValuesFile != "" {
+		args = append(args, "-f", r.ValuesFile)
+	}
+
+	out, err := runRenderer(ctx, "helm", args...)
+	if err != nil {
+		return nil, fmt.Errorf("helm template: %w", err)
+	}
+	return labelRendered(out, cfg, annotations)
+}
+
+// KustomizeRenderer runs `kustomize build` against OverlayDir, resolved against
+// the controller's working directory (the PR's repo is not cloned for rendering).
+type KustomizeRenderer struct {
+	OverlayDir string
+}
+
+func (r KustomizeRenderer) Render(ctx context.Context, cfg PreviewConfig, annotations map[string]string) ([]*unstructured.Unstructured, error) {
+	if r.OverlayDir == "" {
+		return nil, fmt.Errorf("kustomize renderer: overlay dir is empty")
+	}
+
+	out, err := runRenderer(ctx, "kustomize", "build", r.OverlayDir)
+	if err != nil {
+		return nil, fmt.Errorf("kustomize build: %w", err)
+	}
+	return labelRendered(out, cfg, annotations)
+}
+
+// TemplateRenderer renders a directory of Go-templated YAML manifests, resolved
+// against the controller's working directory (the PR's repo is not cloned for
+// rendering): a tiny, file-based alternative to a Helm chart that
+// lets an app define its own readiness probes, resource requests, ConfigMaps,
+// PVCs, or sidecars without forking the controller. Every *.yaml, *.yml,
+// *.yaml.tmpl, and *.yml.tmpl file directly inside Dir is rendered with
+// text/template against a templateContext and concatenated into one
+// multi-doc YAML stream.
+type TemplateRenderer struct {
+	Dir    string
+	Values map[string]interface{}
+}
+
+// templateContext is the data a manifest template is rendered with.
+type templateContext struct {
+	AppName       string
+	PRNumber      int
+	Namespace     string
+	ImageRef      string
+	RouteHost     string
+	HeadSHA       string
+	Env           map[string]string
+	ContainerPort int32
+	Values        map[string]interface{}
+}
+
+func (r TemplateRenderer) Render(_ context.Context, cfg PreviewConfig, annotations map[string]string) ([]*unstructured.Unstructured, error) {
+	if r.Dir == "" {
+		return nil, fmt.Errorf("template renderer: dir is empty")
+	}
+
+	entries, err := os.ReadDir(r.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("read template dir: %w", err)
+	}
+
+	tmplCtx := templateContext{
+		AppName:       cfg.AppName,
+		PRNumber:      cfg.PRNumber,
+		Namespace:     cfg.Namespace,
+		ImageRef:      cfg.ImageRef,
+		RouteHost:     cfg.RouteHost,
+		HeadSHA:       cfg.HeadSHA,
+		Env:           cfg.Env,
+		ContainerPort: cfg.ContainerPort,
+		Values:        r.Values,
+	}
+
+	var rendered bytes.Buffer
+	for _, entry := range entries {
+		if entry.IsDir() || !isManifestTemplate(entry.Name()) {
+			continue
+		}
+
+		path := filepath.Join(r.Dir, entry.Name())
+		tmpl, err := template.New(entry.Name()).ParseFiles(path)
+		if err != nil {
+			return nil, fmt.Errorf("parse template %s: %w", entry.Name(), err)
+		}
+		if rendered.Len() > 0 {
+			rendered.WriteString("\n---\n")
+		}
+		if err := tmpl.ExecuteTemplate(&rendered, entry.Name(), tmplCtx); err != nil {
+			return nil, fmt.Errorf("render template %s: %w", entry.Name(), err)
+		}
+	}
+
+	if rendered.Len() == 0 {
+		return nil, fmt.Errorf("template renderer: no manifest templates found in %s", r.Dir)
+	}
+
+	return labelRendered(rendered.Bytes(), cfg, annotations)
+}
+
+func isManifestTemplate(name string) bool {
+	for _, suffix := range []string{".yaml", ".yml", ".yaml.tmpl", ".yml.tmpl"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func runRenderer(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// labelRendered decodes a multi-document YAML stream and stamps every object with the
+// preview's labels/annotations so the sync engine can track and prune it later.
+func labelRendered(multiDocYAML []byte, cfg PreviewConfig, annotations map[string]string) ([]*unstructured.Unstructured, error) {
+	labels := Labels(cfg)
+	var objects []*unstructured.Unstructured
+
+	for _, doc := range splitYAMLDocuments(multiDocYAML) {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		raw, err := yaml.YAMLToJSON([]byte(doc))
+		if err != nil {
+			return nil, fmt.Errorf("decode rendered manifest: %w", err)
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(raw); err != nil {
+			return nil, fmt.Errorf("unmarshal rendered manifest: %w", err)
+		}
+		if obj.GetKind() == "" {
+			continue
+		}
+
+		obj.SetNamespace(cfg.Namespace)
+		mergedLabels := obj.GetLabels()
+		if mergedLabels == nil {
+			mergedLabels = map[string]string{}
+		}
+		for k, v := range labels {
+			mergedLabels[k] = v
+		}
+		obj.SetLabels(mergedLabels)
+
+		mergedAnnotations := obj.GetAnnotations()
+		if mergedAnnotations == nil {
+			mergedAnnotations = map[string]string{}
+		}
+		for k, v := range annotations {
+			mergedAnnotations[k] = v
+		}
+		obj.SetAnnotations(mergedAnnotations)
+
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+func splitYAMLDocuments(multiDocYAML []byte) []string {
+	return strings.Split(string(multiDocYAML), "\n---\n")
+}
+
+func imageRepository(imageRef string) string {
+	if at := strings.LastIndex(imageRef, ":"); at != -1 && !strings.Contains(imageRef[at:], "/") {
+		return imageRef[:at]
+	}
+	return imageRef
+}
+
+func imageTagOf(imageRef string) string {
+	if at := strings.LastIndex(imageRef, ":"); at != -1 && !strings.Contains(imageRef[at:], "/") {
+		return imageRef[at+1:]
+	}
+	return "latest"
+}
@@ -1,6 +1,8 @@
 package reconcile
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"sort"
 	"strings"
@@ -23,10 +25,13 @@ func Labels(cfg PreviewConfig) map[string]string {
 	}
 }
 
-func Annotations(cfg PreviewConfig, now time.Time, createdAt string) map[string]string {
+// Annotations builds the bookkeeping annotations stamped onto every resource
+// a preview owns.
+func Annotations(cfg PreviewConfig, now time.Time, createdAt, lastUpdatedAt string) map[string]string {
 	annotations := map[string]string{
 		"preview-controller/head-sha":        cfg.HeadSHA,
-		"preview-controller/last-updated-at": now.UTC().Format(time.RFC3339),
+		"preview-controller/last-updated-at": lastUpdatedAt,
+		"preview-controller/instance-id":     instanceID(cfg),
 	}
 	if createdAt == "" {
 		annotations["preview-controller/created-at"] = now.UTC().Format(time.RFC3339)
@@ -36,11 +41,24 @@ func Annotations(cfg PreviewConfig, now time.Time, createdAt string) map[string]
 	return annotations
 }
 
+// instanceID is a short, stable hash of a preview's repo+PR, stamped on every
+// resource it owns as a gitops-engine-style "app instance" identity. Sync
+// still lists and prunes by the preview-controller/pr + preview-controller/repo
+// labels, not this annotation -- a label selector is what the dynamic client
+// can actually query on. instance-id exists so a resource found some other
+// way (e.g. by kind/name during a manual audit) can still be traced back to
+// the PR that owns it even if its labels were ever stripped or edited.
+func instanceID(cfg PreviewConfig) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s#%d", cfg.RepoFullName, cfg.PRNumber)))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
 func BuildDeployment(cfg PreviewConfig, annotations map[string]string) *appsv1.Deployment {
 	labels := Labels(cfg)
 	name := ResourcePrefix(cfg.AppName, cfg.PRNumber)
 
 	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        name,
 			Namespace:   cfg.Namespace,
@@ -72,6 +90,7 @@ func BuildService(cfg PreviewConfig, annotations map[string]string) *corev1.Serv
 	name := ResourcePrefix(cfg.AppName, cfg.PRNumber)
 
 	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        name,
 			Namespace:   cfg.Namespace,
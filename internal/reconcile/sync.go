@@ -0,0 +1,160 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+
+	"auto-deployer/internal/openshift"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// SyncState classifies a resource relative to the desired set computed for a preview.
+type SyncState string
+
+const (
+	InSync    SyncState = "InSync"
+	OutOfSync SyncState = "OutOfSync"
+	Extra     SyncState = "Extra"
+)
+
+// TrackedGVRs are the resource kinds the sync engine considers part of a preview's
+// declarative set. Anything carrying the preview labels outside this list is left alone.
+var TrackedGVRs = []schema.GroupVersionResource{
+	{Group: "apps", Version: "v1", Resource: "deployments"},
+	{Group: "", Version: "v1", Resource: "services"},
+	{Group: "route.openshift.io", Version: "v1", Resource: "routes"},
+	{Group: "", Version: "v1", Resource: "configmaps"},
+	{Group: "", Version: "v1", Resource: "secrets"},
+	{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"},
+	{Group: "", Version: "v1", Resource: "persistentvolumeclaims"},
+}
+
+// ResourcePlan describes what the sync engine intends to do with a single resource.
+type ResourcePlan struct {
+	GVR   schema.GroupVersionResource
+	Name  string
+	State SyncState
+}
+
+// ResourceDiff records what Apply's server-side apply found had actually
+// changed for one resource, so a reconcile can log real drift instead of
+// just "applied".
+type ResourceDiff struct {
+	GVR           schema.GroupVersionResource
+	Name          string
+	ChangedFields []string
+}
+
+// SyncResult is the outcome of reconciling a preview's declarative resource
+// set against what's live in the namespace.
+type SyncResult struct {
+	Namespace string
+	Plan      []ResourcePlan
+	Applied   int
+	Pruned    int
+	// Diffs holds one entry per applied resource that server-side apply found
+	// had actually changed. An apply with no matching Diffs entry was a no-op.
+	Diffs []ResourceDiff
+}
+
+// Sync applies desired against what's live, creating/updating everything in desired
+// and pruning any tracked resource carrying the preview's labels that isn't in it.
+// Calling Sync with an empty desired set deletes the whole preview, which is how
+// DeletePreview is implemented.
+func Sync(ctx context.Context, client *openshift.Client, cfg PreviewConfig, desired []*unstructured.Unstructured) (SyncResult, error) {
+	result := SyncResult{Namespace: cfg.Namespace}
+	selector := labelSelector(cfg)
+
+	desiredByGVR := make(map[schema.GroupVersionResource]map[string]*unstructured.Unstructured)
+	for _, obj := range desired {
+		gvr, err := gvrForObject(obj)
+		if err != nil {
+			return result, err
+		}
+		if desiredByGVR[gvr] == nil {
+			desiredByGVR[gvr] = make(map[string]*unstructured.Unstructured)
+		}
+		desiredByGVR[gvr][obj.GetName()] = obj
+	}
+
+	for _, gvr := range TrackedGVRs {
+		res := client.Dynamic.Resource(gvr).Namespace(cfg.Namespace)
+
+		live, err := res.List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return result, fmt.Errorf("list %s: %w", gvr.Resource, err)
+		}
+		liveByName := make(map[string]unstructured.Unstructured, len(live.Items))
+		for _, item := range live.Items {
+			liveByName[item.GetName()] = item
+		}
+
+		for name, obj := range desiredByGVR[gvr] {
+			_, exists := liveByName[name]
+			state := OutOfSync
+			if exists {
+				state = InSync
+			}
+			result.Plan = append(result.Plan, ResourcePlan{GVR: gvr, Name: name, State: state})
+
+			diff, err := client.Apply(ctx, gvr, cfg.Namespace, obj)
+			if err != nil {
+				return result, fmt.Errorf("apply %s/%s: %w", gvr.Resource, name, err)
+			}
+			if diff.Changed {
+				result.Diffs = append(result.Diffs, ResourceDiff{GVR: gvr, Name: name, ChangedFields: diff.ChangedFields})
+			}
+			result.Applied++
+		}
+
+		for name := range liveByName {
+			if _, wanted := desiredByGVR[gvr][name]; wanted {
+				continue
+			}
+			result.Plan = append(result.Plan, ResourcePlan{GVR: gvr, Name: name, State: Extra})
+			if err := res.Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+				return result, fmt.Errorf("prune %s/%s: %w", gvr.Resource, name, err)
+			}
+			result.Pruned++
+		}
+	}
+
+	return result, nil
+}
+
+func gvrForObject(obj *unstructured.Unstructured) (schema.GroupVersionResource, error) {
+	switch obj.GetKind() {
+	case "Deployment":
+		return schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, nil
+	case "Service":
+		return schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"}, nil
+	case "Route":
+		return openshift.RouteGVR, nil
+	case "ConfigMap":
+		return schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}, nil
+	case "Secret":
+		return schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}, nil
+	case "HorizontalPodAutoscaler":
+		return schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}, nil
+	case "PersistentVolumeClaim":
+		return schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumeclaims"}, nil
+	default:
+		return schema.GroupVersionResource{}, fmt.Errorf("unsupported kind for sync: %s", obj.GetKind())
+	}
+}
+
+// toUnstructured converts a typed object (Deployment, Service, ...) into the
+// unstructured form the sync engine and dynamic client operate on.
+func toUnstructured(obj interface{}) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u, nil
+	}
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("convert to unstructured: %w", err)
+	}
+	return &unstructured.Unstructured{Object: raw}, nil
+}
@@ -0,0 +1,174 @@
+package reconcile
+
+import (
+	"context"
+	"testing"
+
+	"auto-deployer/internal/openshift"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// gvrToListKind covers every GVR sync.go tracks, so the fake dynamic client
+// can serve List for a resource even before any instance of it exists.
+var gvrToListKind = map[schema.GroupVersionResource]string{
+	{Group: "apps", Version: "v1", Resource: "deployments"}: "DeploymentList",
+	{Group: "", Version: "v1", Resource: "services"}:        "ServiceList",
+	openshift.RouteGVR: "RouteList",
+	{Group: "", Version: "v1", Resource: "configmaps"}:                          "ConfigMapList",
+	{Group: "", Version: "v1", Resource: "secrets"}:                             "SecretList",
+	{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}: "HorizontalPodAutoscalerList",
+	{Group: "", Version: "v1", Resource: "persistentvolumeclaims"}:              "PersistentVolumeClaimList",
+}
+
+func newTestClient(t *testing.T, objs ...runtime.Object) *openshift.Client {
+	t.Helper()
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrToListKind, objs...)
+	return &openshift.Client{Dynamic: dyn}
+}
+
+func testCfg() PreviewConfig {
+	return PreviewConfig{
+		AppName:      "demo",
+		Namespace:    "demo-pr-7",
+		PRNumber:     7,
+		RepoFullName: "hci-gu/demo",
+	}
+}
+
+func labeledUnstructured(kind, name, namespace string, extraLabels map[string]string) *unstructured.Unstructured {
+	gvk := map[string]string{
+		"Deployment": "apps/v1",
+		"Service":    "v1",
+		"Route":      "route.openshift.io/v1",
+	}
+	labels := map[string]interface{}{}
+	for k, v := range extraLabels {
+		labels[k] = v
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": gvk[kind],
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    labels,
+		},
+	}}
+}
+
+func TestSyncCreatesMissingResources(t *testing.T) {
+	cfg := testCfg()
+	client := newTestClient(t)
+
+	desired := []*unstructured.Unstructured{
+		labeledUnstructured("Deployment", "demo-pr-7", cfg.Namespace, Labels(cfg)),
+		labeledUnstructured("Service", "demo-pr-7", cfg.Namespace, Labels(cfg)),
+	}
+
+	result, err := Sync(context.Background(), client, cfg, desired)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if result.Applied != 2 {
+		t.Fatalf("expected 2 resources applied, got %d (%+v)", result.Applied, result)
+	}
+	if result.Pruned != 0 {
+		t.Fatalf("expected nothing pruned, got %d", result.Pruned)
+	}
+
+	for _, plan := range result.Plan {
+		if plan.State != OutOfSync {
+			t.Fatalf("expected a fresh namespace's plan to be all OutOfSync, got %+v", plan)
+		}
+	}
+
+	// Applying again against what's now live should report InSync, not
+	// OutOfSync, since the desired set hasn't changed.
+	result, err = Sync(context.Background(), client, cfg, desired)
+	if err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	for _, plan := range result.Plan {
+		if plan.State != InSync {
+			t.Fatalf("expected re-sync of an unchanged desired set to be InSync, got %+v", plan)
+		}
+	}
+}
+
+func TestSyncPrunesResourcesNotInDesiredSet(t *testing.T) {
+	cfg := testCfg()
+	labels := Labels(cfg)
+
+	existingDeployment := labeledUnstructured("Deployment", "demo-pr-7", cfg.Namespace, labels)
+	existingService := labeledUnstructured("Service", "demo-pr-7-orphan", cfg.Namespace, labels)
+	client := newTestClient(t, existingDeployment, existingService)
+
+	desired := []*unstructured.Unstructured{
+		labeledUnstructured("Deployment", "demo-pr-7", cfg.Namespace, labels),
+	}
+
+	result, err := Sync(context.Background(), client, cfg, desired)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if result.Pruned != 1 {
+		t.Fatalf("expected the orphaned service to be pruned, got %d (%+v)", result.Pruned, result)
+	}
+
+	var sawExtra bool
+	for _, plan := range result.Plan {
+		if plan.Name == "demo-pr-7-orphan" {
+			sawExtra = true
+			if plan.State != Extra {
+				t.Fatalf("expected orphaned service to be classified Extra, got %s", plan.State)
+			}
+		}
+	}
+	if !sawExtra {
+		t.Fatalf("expected a plan entry for the orphaned service, got %+v", result.Plan)
+	}
+}
+
+func TestSyncWithEmptyDesiredSetPrunesEverything(t *testing.T) {
+	cfg := testCfg()
+	labels := Labels(cfg)
+
+	client := newTestClient(t,
+		labeledUnstructured("Deployment", "demo-pr-7", cfg.Namespace, labels),
+		labeledUnstructured("Service", "demo-pr-7", cfg.Namespace, labels),
+	)
+
+	result, err := Sync(context.Background(), client, cfg, nil)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if result.Applied != 0 {
+		t.Fatalf("expected nothing applied, got %d", result.Applied)
+	}
+	if result.Pruned != 2 {
+		t.Fatalf("expected both resources pruned, got %d (%+v)", result.Pruned, result)
+	}
+}
+
+func TestSyncIgnoresResourcesOutsideTheLabelSelector(t *testing.T) {
+	cfg := testCfg()
+
+	otherPreview := labeledUnstructured("Deployment", "other-pr-9", cfg.Namespace, Labels(PreviewConfig{
+		AppName:      "demo",
+		PRNumber:     9,
+		RepoFullName: "hci-gu/demo",
+	}))
+	client := newTestClient(t, otherPreview)
+
+	result, err := Sync(context.Background(), client, cfg, nil)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if result.Pruned != 0 {
+		t.Fatalf("expected a different PR's resources to be left alone, got %d pruned (%+v)", result.Pruned, result)
+	}
+}
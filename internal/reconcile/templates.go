@@ -22,6 +22,13 @@ func ImageTag(strategy string, prNumber int, sha string) (string, error) {
 	}
 }
 
+// CacheImageRef renders imageTemplate with a tag derived from app instead of
+// a build's tag, giving every PR of an app a stable image it can push and
+// pull layer-cache metadata from across builds.
+func CacheImageRef(imageTemplate string, app string, prNumber int) (string, error) {
+	return RenderTemplate(imageTemplate, app, "cache-"+app, prNumber)
+}
+
 func RenderTemplate(template string, app string, tag string, prNumber int) (string, error) {
 	if template == "" {
 		return "", fmt.Errorf("template is empty")
@@ -1,14 +1,20 @@
 package reconcile
 
 type PreviewConfig struct {
-	AppName       string
-	Namespace     string
-	PRNumber      int
-	RepoFullName  string
-	ImageRef      string
-	ContainerPort int32
-	RouteHost     string
-	RoutePath     string
-	HeadSHA       string
-	Env           map[string]string
+	AppName         string
+	Namespace       string
+	PRNumber        int
+	RepoFullName    string
+	ImageRef        string
+	ContainerPort   int32
+	RouteHost       string
+	RoutePath       string
+	HeadSHA         string
+	Env             map[string]string
+	NamespacePolicy NamespacePolicy
+	// InstallationID is the GitHub App installation this preview's webhook
+	// arrived through, if any. Zero means either no GitHub App is configured
+	// or the forge isn't GitHub; github.Client falls back to its configured
+	// default installation (or static token) in that case.
+	InstallationID int64
 }
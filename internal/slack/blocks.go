@@ -0,0 +1,60 @@
+package slack
+
+// Block is a single Slack Block Kit layout block
+// (https://api.slack.com/reference/block-kit/blocks), passed through to the
+// API verbatim. The constructors below cover the handful of shapes
+// processPreviewJob's lifecycle notifications need; anything fancier can be
+// built by hand as a plain Block.
+type Block map[string]interface{}
+
+const headerTextMaxLen = 150
+
+// HeaderBlock renders text as a message's bold header line.
+func HeaderBlock(text string) Block {
+	return Block{
+		"type": "header",
+		"text": map[string]interface{}{
+			"type":  "plain_text",
+			"text":  truncate(text, headerTextMaxLen),
+			"emoji": true,
+		},
+	}
+}
+
+// SectionBlock renders text as markdown, optionally with a grid of short
+// field strings (also markdown) beneath it.
+func SectionBlock(text string, fields ...string) Block {
+	block := Block{
+		"type": "section",
+		"text": map[string]interface{}{"type": "mrkdwn", "text": text},
+	}
+	if len(fields) > 0 {
+		fieldObjs := make([]map[string]interface{}, len(fields))
+		for i, field := range fields {
+			fieldObjs[i] = map[string]interface{}{"type": "mrkdwn", "text": field}
+		}
+		block["fields"] = fieldObjs
+	}
+	return block
+}
+
+// ActionsBlock groups interactive elements, such as ButtonElement, into a row.
+func ActionsBlock(elements ...Block) Block {
+	return Block{"type": "actions", "elements": elements}
+}
+
+// ButtonElement is a link button for use inside an ActionsBlock.
+func ButtonElement(label, url string) Block {
+	return Block{
+		"type": "button",
+		"text": map[string]interface{}{"type": "plain_text", "text": label, "emoji": true},
+		"url":  url,
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
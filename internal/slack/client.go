@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,6 +19,9 @@ type Client struct {
 	botToken   string
 	channelID  string
 	httpClient *http.Client
+
+	mu      sync.Mutex
+	threads map[threadKey]string
 }
 
 func NewClient(webhookURL, botToken, channelID string) *Client {
@@ -34,6 +38,7 @@ func NewClient(webhookURL, botToken, channelID string) *Client {
 		botToken:   botToken,
 		channelID:  channelID,
 		httpClient: &http.Client{Timeout: 10 * time.Second},
+		threads:    make(map[threadKey]string),
 	}
 }
 
@@ -136,6 +141,121 @@ func (c *Client) postMessage(ctx context.Context, text string) error {
 	return nil
 }
 
+// SendBlocks posts a Block Kit message. fallback is used as the plain-text
+// notification shown in clients that can't render blocks (e.g. push
+// notifications) and, for the webhook transport, as a minimal substitute
+// since incoming webhooks don't support thread replies at all.
+//
+// If ctx carries a thread key (see ContextWithThread), and this Client is
+// configured for the chat.postMessage transport, the message is posted as a
+// reply in that PR's thread: the first message for a given (repo, PR) starts
+// the thread, and every later one replies to it.
+func (c *Client) SendBlocks(ctx context.Context, blocks []Block, fallback string) error {
+	if c == nil {
+		return fmt.Errorf("slack client is nil")
+	}
+	if len(blocks) == 0 {
+		return fmt.Errorf("blocks is empty")
+	}
+
+	if c.webhookURL != "" {
+		return c.sendBlocksWebhook(ctx, blocks, fallback)
+	}
+	return c.postBlocksMessage(ctx, blocks, fallback)
+}
+
+func (c *Client) sendBlocksWebhook(ctx context.Context, blocks []Block, fallback string) error {
+	payload := map[string]interface{}{"text": fallback, "blocks": blocks}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.webhookURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<10))
+		return fmt.Errorf("webhook failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+	return nil
+}
+
+func (c *Client) postBlocksMessage(ctx context.Context, blocks []Block, fallback string) error {
+	key, hasThread := threadFromContext(ctx)
+	var threadTS string
+	if hasThread {
+		c.mu.Lock()
+		threadTS = c.threads[key]
+		c.mu.Unlock()
+	}
+
+	payload := map[string]interface{}{
+		"channel": c.channelID,
+		"text":    fallback,
+		"blocks":  blocks,
+	}
+	if threadTS != "" {
+		payload["thread_ts"] = threadTS
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal postMessage payload: %w", err)
+	}
+
+	url := apiBaseURL + "/chat.postMessage"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create postMessage request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.botToken)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send postMessage request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 32<<10))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("postMessage failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+
+	var parsed struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+		TS    string `json:"ts"`
+	}
+	if err := json.Unmarshal(bodyBytes, &parsed); err == nil {
+		if !parsed.OK {
+			if strings.Contains(parsed.Error, "not_in_channel") {
+				if joinErr := c.joinChannel(ctx); joinErr != nil {
+					return fmt.Errorf("postMessage error: not_in_channel (also failed to join: %w)", joinErr)
+				}
+				return c.postBlocksMessage(ctx, blocks, fallback)
+			}
+			return fmt.Errorf("postMessage error: %s", parsed.Error)
+		}
+		if hasThread && threadTS == "" && parsed.TS != "" {
+			c.mu.Lock()
+			c.threads[key] = parsed.TS
+			c.mu.Unlock()
+		}
+	}
+
+	return nil
+}
+
 func (c *Client) joinChannel(ctx context.Context) error {
 	payload := map[string]string{
 		"channel": c.channelID,
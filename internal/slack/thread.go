@@ -0,0 +1,27 @@
+package slack
+
+import "context"
+
+// threadKey identifies the preview whose lifecycle messages should be
+// threaded together.
+type threadKey struct {
+	Repo     string
+	PRNumber int
+}
+
+// threadKeyContextKey is the context key ContextWithThread stores a threadKey
+// under, mirroring github.DeliveryID's context-propagation pattern.
+type threadKeyContextKey struct{}
+
+// ContextWithThread tags ctx with the (repo, PR) a SendBlocks call on behalf
+// of this ctx belongs to. SendBlocks uses it to post every message for that
+// PR as a reply in the same Slack thread instead of as a new top-level
+// message.
+func ContextWithThread(ctx context.Context, repoFullName string, prNumber int) context.Context {
+	return context.WithValue(ctx, threadKeyContextKey{}, threadKey{Repo: repoFullName, PRNumber: prNumber})
+}
+
+func threadFromContext(ctx context.Context) (threadKey, bool) {
+	key, ok := ctx.Value(threadKeyContextKey{}).(threadKey)
+	return key, ok
+}
@@ -0,0 +1,114 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// BitbucketProvider speaks Bitbucket Cloud's pullrequest webhooks. Signature
+// verification reuses GitHub's "sha256=<hex>" X-Hub-Signature scheme, but event
+// names and action vocabulary are Bitbucket's own.
+type BitbucketProvider struct{}
+
+const bitbucketSignaturePrefix = "sha256="
+
+func (BitbucketProvider) Name() string { return "bitbucket" }
+
+func (BitbucketProvider) VerifySignature(secret string, header http.Header, body []byte) bool {
+	if secret == "" {
+		return false
+	}
+	signature := header.Get("X-Hub-Signature")
+	if !strings.HasPrefix(signature, bitbucketSignaturePrefix) {
+		return false
+	}
+	got := signature[len(bitbucketSignaturePrefix):]
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(got))
+}
+
+func (BitbucketProvider) EventType(header http.Header) (string, bool) {
+	event := header.Get("X-Event-Key")
+	if event == "" {
+		return "", false
+	}
+	return event, true
+}
+
+type bitbucketPullRequestPayload struct {
+	PullRequest struct {
+		ID     int `json:"id"`
+		Source struct {
+			Commit struct {
+				Hash string `json:"hash"`
+			} `json:"commit"`
+			Repository struct {
+				FullName string `json:"full_name"`
+			} `json:"repository"`
+		} `json:"source"`
+	} `json:"pullrequest"`
+	Repository struct {
+		FullName string `json:"full_name"`
+		Links    struct {
+			Clone []struct {
+				Name string `json:"name"`
+				Href string `json:"href"`
+			} `json:"clone"`
+		} `json:"links"`
+	} `json:"repository"`
+}
+
+func (p bitbucketPullRequestPayload) cloneURL() string {
+	for _, link := range p.Repository.Links.Clone {
+		if link.Name == "https" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+func (BitbucketProvider) Normalize(eventType string, body []byte) (PullRequestEvent, bool, error) {
+	action, merged, ok := normalizeBitbucketAction(eventType)
+	if !ok {
+		return PullRequestEvent{}, false, nil
+	}
+
+	var payload bitbucketPullRequestPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return PullRequestEvent{}, false, err
+	}
+
+	return PullRequestEvent{
+		Repo:     payload.Repository.FullName,
+		Number:   payload.PullRequest.ID,
+		HeadSHA:  payload.PullRequest.Source.Commit.Hash,
+		CloneURL: payload.cloneURL(),
+		Action:   action,
+		Merged:   merged,
+		IsFork:   payload.PullRequest.Source.Repository.FullName != payload.Repository.FullName,
+	}, true, nil
+}
+
+// normalizeBitbucketAction maps Bitbucket's pullrequest:* event keys onto the
+// "opened"/"synchronize"/"closed" vocabulary previewDispatcher switches on.
+// Bitbucket Cloud has no "reopened" pull request webhook event.
+func normalizeBitbucketAction(eventType string) (normalized string, merged bool, ok bool) {
+	switch eventType {
+	case "pullrequest:created":
+		return "opened", false, true
+	case "pullrequest:updated":
+		return "synchronize", false, true
+	case "pullrequest:fulfilled":
+		return "closed", true, true
+	case "pullrequest:rejected":
+		return "closed", false, true
+	default:
+		return "", false, false
+	}
+}
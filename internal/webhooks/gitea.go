@@ -0,0 +1,84 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+)
+
+// GiteaProvider speaks Gitea's pull_request webhooks. Gitea signs with
+// HMAC-SHA256 like GitHub, but X-Gitea-Signature carries the bare hex digest
+// with no "sha256=" prefix, and its event header is X-Gitea-Event.
+type GiteaProvider struct{}
+
+func (GiteaProvider) Name() string { return "gitea" }
+
+func (GiteaProvider) VerifySignature(secret string, header http.Header, body []byte) bool {
+	if secret == "" {
+		return false
+	}
+	signature := header.Get("X-Gitea-Signature")
+	if signature == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func (GiteaProvider) EventType(header http.Header) (string, bool) {
+	event := header.Get("X-Gitea-Event")
+	if event == "" {
+		return "", false
+	}
+	return event, true
+}
+
+type giteaPullRequestPayload struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Number int  `json:"number"`
+		Merged bool `json:"merged"`
+		Head   struct {
+			SHA  string `json:"sha"`
+			Repo struct {
+				FullName string `json:"full_name"`
+			} `json:"repo"`
+		} `json:"head"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+		CloneURL string `json:"clone_url"`
+	} `json:"repository"`
+}
+
+func (GiteaProvider) Normalize(eventType string, body []byte) (PullRequestEvent, bool, error) {
+	if eventType != "pull_request" {
+		return PullRequestEvent{}, false, nil
+	}
+
+	var payload giteaPullRequestPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return PullRequestEvent{}, false, err
+	}
+
+	// Gitea reuses GitHub's pull_request action vocabulary verbatim.
+	switch payload.Action {
+	case "opened", "reopened", "synchronize", "closed":
+	default:
+		return PullRequestEvent{}, false, nil
+	}
+
+	return PullRequestEvent{
+		Repo:     payload.Repository.FullName,
+		Number:   payload.PullRequest.Number,
+		HeadSHA:  payload.PullRequest.Head.SHA,
+		CloneURL: payload.Repository.CloneURL,
+		Action:   payload.Action,
+		Merged:   payload.PullRequest.Merged,
+		IsFork:   payload.PullRequest.Head.Repo.FullName != payload.Repository.FullName,
+	}, true, nil
+}
@@ -0,0 +1,52 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"auto-deployer/internal/github"
+)
+
+// GitHubProvider adapts internal/github's signature scheme and pull_request
+// payload shape to Provider. The richer GitHub dispatch (repository, push,
+// check_run, installation events) keeps using github.NewWebhookHandler directly;
+// this provider only exists so /webhook/github can also be reached through the
+// same generic NewWebhookHandler as the other forges, if ever wired that way.
+type GitHubProvider struct{}
+
+func (GitHubProvider) Name() string { return "github" }
+
+func (GitHubProvider) VerifySignature(secret string, header http.Header, body []byte) bool {
+	return github.VerifySignature(secret, body, header.Get("X-Hub-Signature-256"))
+}
+
+func (GitHubProvider) EventType(header http.Header) (string, bool) {
+	event := header.Get("X-GitHub-Event")
+	if event == "" {
+		return "", false
+	}
+	return event, true
+}
+
+func (GitHubProvider) Normalize(eventType string, body []byte) (PullRequestEvent, bool, error) {
+	if eventType != github.EventPullRequest {
+		return PullRequestEvent{}, false, nil
+	}
+	payload, err := github.ParsePullRequestEvent(body)
+	if err != nil {
+		return PullRequestEvent{}, false, err
+	}
+	var installationID int64
+	if payload.Installation != nil {
+		installationID = payload.Installation.ID
+	}
+	return PullRequestEvent{
+		Repo:           payload.Repository.FullName,
+		Number:         payload.PullRequest.Number,
+		HeadSHA:        payload.PullRequest.Head.SHA,
+		CloneURL:       payload.Repository.CloneURL,
+		Action:         payload.Action,
+		Merged:         payload.PullRequest.Merged,
+		IsFork:         payload.PullRequest.Head.Repo.FullName != payload.Repository.FullName,
+		InstallationID: installationID,
+	}, true, nil
+}
@@ -0,0 +1,103 @@
+package webhooks
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+)
+
+// GitLabProvider speaks GitLab's merge request webhooks: X-Gitlab-Token is
+// compared directly against the configured secret (GitLab has no HMAC scheme),
+// and X-Gitlab-Event names the hook ("Merge Request Hook" is the only one this
+// provider translates).
+type GitLabProvider struct{}
+
+const gitlabMergeRequestEvent = "Merge Request Hook"
+
+func (GitLabProvider) Name() string { return "gitlab" }
+
+func (GitLabProvider) VerifySignature(secret string, header http.Header, body []byte) bool {
+	if secret == "" {
+		return false
+	}
+	token := header.Get("X-Gitlab-Token")
+	if token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+func (GitLabProvider) EventType(header http.Header) (string, bool) {
+	event := header.Get("X-Gitlab-Event")
+	if event == "" {
+		return "", false
+	}
+	return event, true
+}
+
+type gitlabMergeRequestPayload struct {
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+		GitHTTPURL        string `json:"git_http_url"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID        int    `json:"iid"`
+		Action     string `json:"action"`
+		LastCommit struct {
+			ID string `json:"id"`
+		} `json:"last_commit"`
+		Source struct {
+			PathWithNamespace string `json:"path_with_namespace"`
+		} `json:"source"`
+		Target struct {
+			PathWithNamespace string `json:"path_with_namespace"`
+		} `json:"target"`
+	} `json:"object_attributes"`
+}
+
+func (GitLabProvider) Normalize(eventType string, body []byte) (PullRequestEvent, bool, error) {
+	if eventType != gitlabMergeRequestEvent {
+		return PullRequestEvent{}, false, nil
+	}
+
+	var payload gitlabMergeRequestPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return PullRequestEvent{}, false, err
+	}
+
+	action, merged, ok := normalizeGitLabAction(payload.ObjectAttributes.Action)
+	if !ok {
+		return PullRequestEvent{}, false, nil
+	}
+
+	attrs := payload.ObjectAttributes
+	return PullRequestEvent{
+		Repo:     payload.Project.PathWithNamespace,
+		Number:   attrs.IID,
+		HeadSHA:  attrs.LastCommit.ID,
+		CloneURL: payload.Project.GitHTTPURL,
+		Action:   action,
+		Merged:   merged,
+		IsFork:   attrs.Source.PathWithNamespace != attrs.Target.PathWithNamespace,
+	}, true, nil
+}
+
+// normalizeGitLabAction maps a GitLab merge request hook action onto the
+// "opened"/"reopened"/"synchronize"/"closed" vocabulary previewDispatcher
+// switches on, the same one GitHub's pull_request event already uses.
+func normalizeGitLabAction(action string) (normalized string, merged bool, ok bool) {
+	switch action {
+	case "open":
+		return "opened", false, true
+	case "reopen":
+		return "reopened", false, true
+	case "update":
+		return "synchronize", false, true
+	case "close":
+		return "closed", false, true
+	case "merge":
+		return "closed", true, true
+	default:
+		return "", false, false
+	}
+}
@@ -0,0 +1,73 @@
+package webhooks
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+
+	"auto-deployer/internal/metrics"
+)
+
+// defaultMaxBodySize bounds how much of a webhook body NewWebhookHandler will
+// read, to stop a malicious or misbehaving sender from exhausting memory.
+const defaultMaxBodySize = 5 << 20 // 5MB
+
+// NewWebhookHandler returns an http.Handler for a single forge: it verifies the
+// request against provider, parses the body into a PullRequestEvent, and hands
+// it to handler. Requests for event types the provider doesn't translate into a
+// PullRequestEvent are accepted (202) without reaching handler, mirroring
+// github.NewWebhookHandler's treatment of events it doesn't care about.
+func NewWebhookHandler(secret string, provider Provider, handler Handler, maxBodySize int64) http.Handler {
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxBodySize
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if int64(len(body)) > maxBodySize {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if !provider.VerifySignature(secret, r.Header, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		eventType, ok := provider.EventType(r.Header)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		event, ok, err := provider.Normalize(eventType, body)
+		if err != nil {
+			slog.Default().Warn("webhook normalize failed", "provider", provider.Name(), "event", eventType, "error", err)
+			metrics.WebhookEventsTotal.WithLabelValues(eventType, "", "error").Inc()
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !ok {
+			metrics.WebhookEventsTotal.WithLabelValues(eventType, "", "ignored").Inc()
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		if err := handler.Handle(r.Context(), event); err != nil {
+			metrics.WebhookEventsTotal.WithLabelValues(eventType, event.Action, "error").Inc()
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		metrics.WebhookEventsTotal.WithLabelValues(eventType, event.Action, "accepted").Inc()
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
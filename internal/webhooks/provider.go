@@ -0,0 +1,26 @@
+package webhooks
+
+import "net/http"
+
+// Provider adapts one forge's webhook conventions (signature scheme, event
+// header, JSON shape) to the normalized PullRequestEvent NewWebhookHandler
+// hands to a Handler.
+type Provider interface {
+	// Name identifies the provider for logging, e.g. "gitlab".
+	Name() string
+
+	// VerifySignature checks the request's signature/token header against
+	// secret. It must not read body elsewhere; body is passed in verbatim.
+	VerifySignature(secret string, header http.Header, body []byte) bool
+
+	// EventType returns the forge's event name for this request (e.g.
+	// "Merge Request Hook", "pull_request", "pullrequest:created") and false
+	// if the request carries no recognizable event header.
+	EventType(header http.Header) (string, bool)
+
+	// Normalize parses body according to eventType and returns the normalized
+	// event. ok is false for event types this provider doesn't translate into
+	// a PullRequestEvent (e.g. a GitLab "Note Hook"); those requests are
+	// accepted but otherwise ignored.
+	Normalize(eventType string, body []byte) (event PullRequestEvent, ok bool, err error)
+}
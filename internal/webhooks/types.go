@@ -0,0 +1,32 @@
+// Package webhooks provides a forge-agnostic front door for pull request
+// webhooks. internal/github already speaks GitHub's webhook shapes natively for
+// the full range of events auto-deployer reacts to (pull_request, repository,
+// push, check_run, installation); this package adds a thinner Provider
+// abstraction so GitLab, Gitea, and Bitbucket can drive the same preview
+// machinery through a single normalized PullRequestEvent.
+package webhooks
+
+import "context"
+
+// PullRequestEvent is a forge-agnostic view of a pull/merge request webhook.
+// Repo is the normalized "owner/repo"-style identifier used to key MappingFile,
+// regardless of which forge sent the event.
+type PullRequestEvent struct {
+	Repo     string
+	Number   int
+	HeadSHA  string
+	CloneURL string
+	Action   string
+	Merged   bool
+	IsFork   bool
+	// InstallationID is the GitHub App installation the event arrived through.
+	// Always zero for forges other than GitHub.
+	InstallationID int64
+}
+
+// Handler reacts to a normalized PullRequestEvent. previewDispatcher in
+// cmd/preview-controller implements this alongside github.Dispatcher so both
+// the GitHub-native and forge-agnostic webhook paths drive the same jobCoalescer.
+type Handler interface {
+	Handle(ctx context.Context, event PullRequestEvent) error
+}